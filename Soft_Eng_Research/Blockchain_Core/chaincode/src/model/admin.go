@@ -1,24 +1,60 @@
 package model
 
 import (
-	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
 	"fmt"
 	"time"
 )
 
 type Admin struct {
-	AdminID string `json:"admin_id"`
-	Name    string `json:"name"`
+	AdminID    string             `json:"admin_id"`
+	Name       string             `json:"name"`
+	Role       string             `json:"role,omitempty"`
+	PublicKey  ed25519.PublicKey  `json:"public_key,omitempty"`
+	PrivateKey ed25519.PrivateKey `json:"-"`
 }
 
+// GenerateKeys creates a new Ed25519 key pair the admin uses to sign the credentials it issues.
+func (a *Admin) GenerateKeys() error {
+	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return fmt.Errorf("failed to generate keys for admin %s: %w", a.AdminID, err)
+	}
+	a.PublicKey = publicKey
+	a.PrivateKey = privateKey
+	return nil
+}
+
+// SignCredential signs the credential's hash with the admin's private key and records the admin as its signer.
+func (a *Admin) SignCredential(cred *Credential) error {
+	if len(a.PrivateKey) != ed25519.PrivateKeySize {
+		return fmt.Errorf("admin %s has no signing key", a.AdminID)
+	}
+	cred.SignerID = a.AdminID
+	cred.HashVersion = CredentialHashVersion
+	cred.Hash = GenerateCredentialHash(cred)
+	cred.Signature = ed25519.Sign(a.PrivateKey, cred.Hash)
+	return nil
+}
+
+// AddNewStudent registers a new student. When the student chain is backed by a ledger,
+// the registration is recorded there before the student is added to the registry.
 func (a *Admin) AddNewStudent(id int, firstName, lastName string, birthDate time.Time, studentNum int, chain *StudentChain) (*Student, error) {
 	if chain.Students == nil {
 		chain.Students = make(map[int]*Student)
 	}
 
+	// Students may have been registered on the ledger since the registry last read it
+	if err := chain.catchUp(); err != nil {
+		return nil, err
+	}
 	if _, exists := chain.Students[id]; exists {
 		return nil, fmt.Errorf("student with ID %d already exists", id)
 	}
+	if _, err := chain.FindStudentByNumber(studentNum); err == nil {
+		return nil, fmt.Errorf("student number %d is already registered", studentNum)
+	}
 
 	student := &Student{
 		ID:        id,
@@ -28,11 +64,42 @@ func (a *Admin) AddNewStudent(id int, firstName, lastName string, birthDate time
 		StudentID: studentNum,
 	}
 
-	chain.Students[id] = student
+	if chain.ledger != nil {
+		// The ledger holds only commitments to the student's details; the disclosures stay with the registry
+		commitments, disclosures, err := commitClaims(StudentClaims(student))
+		if err != nil {
+			return nil, err
+		}
+		registration := &StudentRegistration{
+			ID:           id,
+			Commitments:  commitments,
+			RegisteredBy: a.AdminID,
+			RegisteredAt: time.Now().UTC(),
+		}
+		data, err := EncodePayload(PayloadStudentRegistered, registration)
+		if err != nil {
+			return nil, err
+		}
+		// The ledger checks the registration against every student it has recorded, as for a block from consensus
+		if err := chain.ledger.AddBlockEntries([][]byte{data}, registration.RegisteredAt); err != nil {
+			return nil, fmt.Errorf("failed to record student %d: %w", id, err)
+		}
+		// The registry picks the student up from the ledger, like any other registration, and fills in the details
+		if err := chain.catchUp(); err != nil {
+			return nil, err
+		}
+		if err := chain.RestoreStudentDetails(id, disclosures); err != nil {
+			return nil, err
+		}
+		return chain.Students[id], nil
+	}
+
+	chain.addStudent(student)
 	return student, nil
 }
 
 // AddCredentialAdmin adds a new academic credential to the student's list of academic credentials
+// Chains that require approval for academic credentials only accept it once it has gone through an IssuancePool.
 func (a *Admin) AddCredentialAdmin(s *Student, credentialType CredentialType, issuer string, dateIssued time.Time) bool {
 	// Check if the credential type is academic
 	if credentialType != Academic {
@@ -46,32 +113,38 @@ func (a *Admin) AddCredentialAdmin(s *Student, credentialType CredentialType, is
 		DateIssued: dateIssued,
 	}
 
-	// Validate the credential data
-	if err := ValidateCredentialData(&newCredential); err != nil {
-		return false
-	}
+	return a.IssueCredential(s, &newCredential) == nil
+}
 
-	// Generate and store the credential hash
-	newCredential.Hash = GenerateCredentialHash(&newCredential)
+// IssueCredential issues a credential to the student: it assigns the credential a unique ID,
+// validates it, signs it as the issuer and adds it to the student's list of credentials.
+// Fields such as the validity window must be set on cred before it is issued, since they are covered by the signature.
+func (a *Admin) IssueCredential(s *Student, cred *Credential) error {
+	if err := a.prepareCredential(s, cred); err != nil {
+		return err
+	}
 
 	// Add the credential to the student's list of credentials
-	s.Credentials = append(s.Credentials, &newCredential)
-	return true
+	s.Credentials = append(s.Credentials, cred)
+	return nil
 }
 
-// RevokeCredential revokes a credential of the student
-func RevokeCredential(s *Student, cred Credential) error {
-	for _, storedCred := range s.Credentials {
-		// Check if the hash matches to identify the credential
-		if bytes.Equal(storedCred.Hash, cred.Hash) {
-			if storedCred.Status == "revoked" {
-				return fmt.Errorf("credential is already revoked")
-			}
-
-			// Mark the credential as revoked
-			storedCred.Status = "revoked"
-			return nil
-		}
+// prepareCredential assigns the credential to the student, gives it a unique ID, validates it and signs it as the issuer.
+func (a *Admin) prepareCredential(s *Student, cred *Credential) error {
+	cred.OwnerID = s.ID
+
+	// Assign the credential a unique ID
+	id, err := NewCredentialID()
+	if err != nil {
+		return err
+	}
+	cred.ID = id
+
+	// Validate the credential data
+	if err := ValidateCredentialData(cred); err != nil {
+		return err
 	}
-	return fmt.Errorf("credential not found")
+
+	// Generate the credential hash and sign it as the issuer
+	return a.SignCredential(cred)
 }
@@ -0,0 +1,97 @@
+package model
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSignedCredentialVerifies(t *testing.T) {
+	l := newTestLedger(t)
+	cred := l.add(t, testStudent(1), nil)
+
+	if cred.SignerID != l.signer().AdminID || len(cred.Signature) == 0 {
+		t.Fatalf("credential signed by %q with a %d-byte signature", cred.SignerID, len(cred.Signature))
+	}
+	report := l.chain.VerifyCredential(cred.ID)
+	expectStatus(t, report, StatusValid)
+}
+
+func TestAddCredentialRejectsBadSignatures(t *testing.T) {
+	tests := []struct {
+		name string
+		// spoil changes a signed credential so it must be rejected
+		spoil func(l *testLedger, cred *Credential)
+		want  string
+	}{
+		{
+			name:  "unsigned",
+			spoil: func(_ *testLedger, cred *Credential) { cred.Signature = nil },
+			want:  "not signed",
+		},
+		{
+			name: "unregistered signer",
+			spoil: func(_ *testLedger, cred *Credential) {
+				outsider := &Admin{AdminID: "outsider"}
+				outsider.GenerateKeys()
+				outsider.SignCredential(cred)
+			},
+			want: "not a signer",
+		},
+		{
+			name: "signed with another key",
+			spoil: func(l *testLedger, cred *Credential) {
+				impostor := &Admin{AdminID: l.signer().AdminID}
+				impostor.GenerateKeys()
+				impostor.SignCredential(cred)
+			},
+			want: "invalid issuer signature",
+		},
+		{
+			name: "changed after signing",
+			spoil: func(_ *testLedger, cred *Credential) {
+				cred.OwnerID = 2
+			},
+			want: "invalid issuer signature",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := newTestLedger(t)
+			cred := l.issue(t, testStudent(1), nil)
+			tt.spoil(l, cred)
+			err := l.chain.AddCredentialModel(cred)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("AddCredentialModel returned %v, want an error containing %q", err, tt.want)
+			}
+			if _, err := l.chain.FindCredentialByID(cred.ID); err == nil {
+				t.Fatal("rejected credential is on the chain")
+			}
+		})
+	}
+}
+
+func TestSignCredentialNeedsKey(t *testing.T) {
+	admin := &Admin{AdminID: "keyless"}
+	if err := admin.SignCredential(&Credential{}); err == nil {
+		t.Fatal("admin without a key signed a credential")
+	}
+}
+
+func TestAddCredentialAdminSignsAcademicCredentials(t *testing.T) {
+	l := newTestLedger(t)
+	student := testStudent(1)
+	issued := time.Now().Add(-time.Hour)
+	if l.signer().AddCredentialAdmin(student, NonAcademic, testIssuerName, issued) {
+		t.Fatal("AddCredentialAdmin added a non-academic credential")
+	}
+	if !l.signer().AddCredentialAdmin(student, Academic, testIssuerName, issued) {
+		t.Fatal("AddCredentialAdmin refused an academic credential")
+	}
+	cred := student.Credentials[0]
+	if err := l.chain.AddCredentialModel(cred); err != nil {
+		t.Fatal(err)
+	}
+	expectStatus(t, l.chain.VerifyCredential(cred.ID), StatusValid)
+}
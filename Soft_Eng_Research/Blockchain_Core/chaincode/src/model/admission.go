@@ -0,0 +1,167 @@
+package model
+
+import (
+	"bytes"
+	"fmt"
+	"time"
+)
+
+// blockAdmission tracks what the entries of one block have already claimed, so that a block
+// cannot repeat a credential ID, supersede or revoke a credential twice, or register a student twice.
+type blockAdmission struct {
+	// at is the time of the block; entries are judged as of then rather than by the local clock
+	at         time.Time
+	ids        map[string]bool
+	supersedes map[string]bool
+	revoked    map[string]bool
+	students   map[int]bool
+	// quorums holds the approval quorums set by policies earlier in the block
+	quorums map[CredentialType]int
+}
+
+func newBlockAdmission(at time.Time) *blockAdmission {
+	return &blockAdmission{
+		at:         at,
+		ids:        make(map[string]bool),
+		supersedes: make(map[string]bool),
+		revoked:    make(map[string]bool),
+		students:   make(map[int]bool),
+		quorums:    make(map[CredentialType]int),
+	}
+}
+
+// admitCredential checks that the credential may be added to the chain in the block being built,
+// and sets its hash from its content.
+func (chain *CredentialChain) admitCredential(cred *Credential, block *blockAdmission) error {
+	// Unsigned credentials could never verify, so they are kept off the chain
+	if len(cred.Signature) == 0 {
+		return fmt.Errorf("credential %s is not signed by its issuer", cred.ID)
+	}
+	if err := chain.checkCredentialID(cred); err != nil {
+		return err
+	}
+	if block.ids[cred.ID] {
+		return fmt.Errorf("credential ID %s appears more than once in the block", cred.ID)
+	}
+	block.ids[cred.ID] = true
+
+	// A new version must replace a current credential of the same owner and issuer, once
+	if cred.Supersedes != "" {
+		if err := chain.checkSupersedes(cred); err != nil {
+			return err
+		}
+		if block.supersedes[cred.Supersedes] {
+			return fmt.Errorf("credential %s is superseded more than once in the block", cred.Supersedes)
+		}
+		block.supersedes[cred.Supersedes] = true
+	}
+
+	if err := validateCredentialDataAt(cred, block.at); err != nil {
+		return err
+	}
+	// The legacy hash format is only verified for credentials already on the chain
+	if cred.HashVersion != CredentialHashVersion {
+		return fmt.Errorf("credential %s is hashed with version %d; new credentials must use version %d", cred.ID, cred.HashVersion, CredentialHashVersion)
+	}
+	cred.Hash = GenerateCredentialHash(cred)
+
+	// Only registered, active issuers may add credentials
+	if err := chain.checkIssuerTrust(cred, block.at); err != nil {
+		return err
+	}
+
+	// The signature must come from one of the issuer's registered signers
+	if err := chain.verifyIssuerSignature(cred); err != nil {
+		return err
+	}
+
+	// Credential types that need sign-off by several admins must carry enough approvals
+	quorum, ok := block.quorums[cred.Type]
+	if !ok {
+		quorum = chain.quorum(cred.Type)
+	}
+	return chain.checkApprovals(cred, quorum)
+}
+
+// admitRevocation checks that the revocation is signed by an admin allowed to revoke a credential
+// that is on the chain and not yet revoked.
+func (chain *CredentialChain) admitRevocation(revocation *Revocation, block *blockAdmission) error {
+	if revocation.Reason == "" {
+		return fmt.Errorf("revocation reason cannot be empty")
+	}
+	if err := chain.verifyRevocation(revocation); err != nil {
+		return err
+	}
+	if existing, _ := chain.FindRevocation(revocation.CredentialID); existing != nil || block.revoked[revocation.CredentialID] {
+		return fmt.Errorf("credential %s is already revoked", revocation.CredentialID)
+	}
+	block.revoked[revocation.CredentialID] = true
+	return nil
+}
+
+// admitStudent checks that the student's ID is not already registered, and that the registration commits to
+// the student's details rather than recording them in clear. The student number is committed too, so it is
+// checked by the registry that registers the student rather than by the ledger.
+func (chain *CredentialChain) admitStudent(registration *StudentRegistration, block *blockAdmission) error {
+	if registration.Student != nil {
+		return fmt.Errorf("registration of student %d records their details in clear", registration.ID)
+	}
+	idx := chain.indexes()
+	if idx.students[registration.ID] || block.students[registration.ID] {
+		return fmt.Errorf("student with ID %d already exists", registration.ID)
+	}
+	block.students[registration.ID] = true
+	return nil
+}
+
+// AddBlockEntries adds a block holding the given ledger entries, for example one agreed on by the consensus layer.
+// at is the block time agreed with the other replicas: the block is created with it, and credentials' issue dates
+// and expiry are judged as of it rather than by the local clock, so every replica given the same entries and time
+// builds the same block and reaches the same verdict. It must not be before the time of the chain's last block.
+// Each entry is checked as if it had been written through this package: credentials as by AddCredentialBatch,
+// revocations, issuer events and approval policies by their signatures, and student registrations for duplicates.
+// The block is rejected if any entry fails, or cannot be decoded.
+func (chain *CredentialChain) AddBlockEntries(entries [][]byte, at time.Time) error {
+	if len(entries) == 0 {
+		return fmt.Errorf("block must have at least one entry")
+	}
+	if at.IsZero() {
+		return fmt.Errorf("block time must be set")
+	}
+
+	block := newBlockAdmission(at.UTC())
+	for i, entry := range entries {
+		envelope, value, err := DecodePayload(entry)
+		if err != nil {
+			return fmt.Errorf("entry %d: %w", i, err)
+		}
+		switch event := value.(type) {
+		case *Credential:
+			recorded := event.Hash
+			err = chain.admitCredential(event, block)
+			if err == nil && !bytes.Equal(recorded, event.Hash) {
+				err = fmt.Errorf("credential %s content does not match its hash", event.ID)
+			}
+		case *Revocation:
+			err = chain.admitRevocation(event, block)
+		case *IssuerEvent:
+			if issuerEventPayload[event.Action] != envelope.Type {
+				err = fmt.Errorf("%s entry records a %q issuer event", envelope.Type, event.Action)
+			} else {
+				err = chain.verifyIssuerEvent(event)
+			}
+		case *StudentRegistration:
+			err = chain.admitStudent(event, block)
+		case *ApprovalPolicy:
+			if err = chain.verifyApprovalPolicy(event); err == nil {
+				block.quorums[event.Type] = event.Quorum
+			}
+		default:
+			err = fmt.Errorf("%s entries cannot be added to the chain", envelope.Type)
+		}
+		if err != nil {
+			return fmt.Errorf("entry %d: %w", i, err)
+		}
+	}
+	return chain.addBlockEntriesAt(entries, block.at)
+}
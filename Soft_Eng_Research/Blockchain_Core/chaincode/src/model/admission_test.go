@@ -0,0 +1,153 @@
+package model
+
+import (
+	"bytes"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// credentialEntry encodes the credential as a ledger entry.
+func credentialEntry(t *testing.T, cred *Credential) []byte {
+	t.Helper()
+	data, err := EncodePayload(PayloadCredentialIssued, cred)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+// replica loads a copy of the test ledger's chain, as another node holding the same blocks would.
+func (l *testLedger) replica(t *testing.T) *CredentialChain {
+	t.Helper()
+	chain, err := LoadCredentialChain(slices.Clone(l.chain.Blocks), l.chain.SuperAdminKeys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return chain
+}
+
+func TestReplicasBuildTheSameBlock(t *testing.T) {
+	l := newTestLedger(t)
+	l.add(t, testStudent(1), nil)
+	first, second := l.replica(t), l.replica(t)
+
+	entries := [][]byte{credentialEntry(t, l.issue(t, testStudent(2), nil))}
+	at := time.Now().Add(time.Minute)
+	if err := first.AddBlockEntries(entries, at); err != nil {
+		t.Fatal(err)
+	}
+	// The second replica adds the block later by its own clock
+	time.Sleep(time.Millisecond)
+	if err := second.AddBlockEntries(entries, at); err != nil {
+		t.Fatal(err)
+	}
+
+	tip1, tip2 := first.Blocks[len(first.Blocks)-1], second.Blocks[len(second.Blocks)-1]
+	if !tip1.Time.Equal(at) {
+		t.Errorf("block time is %s, want the agreed time %s", tip1.Time, at)
+	}
+	if !bytes.Equal(tip1.Hash, tip2.Hash) {
+		t.Fatal("replicas given the same entries and block time built different blocks")
+	}
+}
+
+func TestAddBlockEntriesJudgesCredentialsAtBlockTime(t *testing.T) {
+	// Every block is dated after the blocks that set up the test ledger
+	now := time.Now().Add(time.Minute)
+	expires := now.Add(time.Hour)
+	tests := []struct {
+		name string
+		edit func(cred *Credential)
+		at   time.Time
+		want string
+	}{
+		{
+			name: "issued after the block",
+			edit: func(cred *Credential) { cred.DateIssued = now.Add(time.Hour) },
+			at:   now,
+			want: "future",
+		},
+		{
+			name: "issued before the block",
+			edit: func(cred *Credential) { cred.DateIssued = now.Add(time.Hour) },
+			at:   now.Add(2 * time.Hour),
+		},
+		{
+			name: "expired by the block",
+			edit: func(cred *Credential) { cred.ExpiresAt = &expires },
+			at:   now.Add(2 * time.Hour),
+			want: "expired",
+		},
+		{
+			name: "expires after the block",
+			edit: func(cred *Credential) { cred.ExpiresAt = &expires },
+			at:   now,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := newTestLedger(t)
+			// The signer checks the credential by its own clock, so the test signs it directly
+			cred := &Credential{Type: Certificate, Issuer: testIssuerName, DateIssued: now.Add(-time.Hour)}
+			tt.edit(cred)
+			cred.OwnerID = testStudent(1).ID
+			id, err := NewCredentialID()
+			if err != nil {
+				t.Fatal(err)
+			}
+			cred.ID = id
+			if err := l.signer().SignCredential(cred); err != nil {
+				t.Fatal(err)
+			}
+
+			err = l.chain.AddBlockEntries([][]byte{credentialEntry(t, cred)}, tt.at)
+			if tt.want == "" {
+				if err != nil {
+					t.Fatalf("credential was rejected at the block time: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("AddBlockEntries returned %v, want an error containing %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestAddBlockEntriesBlockTime(t *testing.T) {
+	l := newTestLedger(t)
+	tip := l.chain.Blocks[len(l.chain.Blocks)-1]
+	entry := credentialEntry(t, l.issue(t, testStudent(1), nil))
+
+	if err := l.chain.AddBlockEntries([][]byte{entry}, time.Time{}); err == nil {
+		t.Error("a block without a time was added")
+	}
+	if err := l.chain.AddBlockEntries([][]byte{entry}, tip.Time.Add(-time.Second)); err == nil {
+		t.Error("a block dated before the last block was added")
+	}
+	if len(l.chain.Blocks) != tip.Index+1 {
+		t.Fatalf("chain has %d blocks after rejected blocks, want %d", len(l.chain.Blocks), tip.Index+1)
+	}
+}
+
+func TestAddBlockEntriesRejectsDuplicateIDs(t *testing.T) {
+	l := newTestLedger(t)
+	onChain := l.add(t, testStudent(1), nil)
+	cred := l.issue(t, testStudent(2), nil)
+
+	if err := l.chain.AddBlockEntries([][]byte{credentialEntry(t, cred), credentialEntry(t, cred)}, time.Now()); err == nil {
+		t.Error("a block repeating a credential ID was added")
+	}
+	if err := l.chain.AddBlockEntries([][]byte{credentialEntry(t, onChain)}, time.Now()); err == nil {
+		t.Error("a credential already on the chain was added again")
+	}
+	if err := l.chain.AddBlockEntries([][]byte{[]byte("not an entry")}, time.Now()); err == nil {
+		t.Error("an undecodable entry was added")
+	}
+	if err := l.chain.AddBlockEntries([][]byte{credentialEntry(t, cred)}, time.Now()); err != nil {
+		t.Fatalf("the credential was rejected on its own: %v", err)
+	}
+}
@@ -0,0 +1,333 @@
+package model
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// DefaultProposalTTL is how long a proposal waits for its approvals before it expires.
+const DefaultProposalTTL = 7 * 24 * time.Hour
+
+// Approval is an admin's signed sign-off on a credential before it is committed to the ledger.
+type Approval struct {
+	AdminID    string    `json:"admin_id"`
+	ApprovedAt time.Time `json:"approved_at"`
+	Signature  []byte    `json:"signature"`
+}
+
+// approvalHash is what an approving admin signs. It is kept apart from the credential hash
+// so an approval cannot be mistaken for the issuer signature.
+func approvalHash(cred *Credential) []byte {
+	hash := sha256.Sum256(append([]byte("approval|"), cred.Hash...))
+	return hash[:]
+}
+
+// ApprovalPolicy is the ledger event setting how many distinct admin approvals credentials of a type need.
+// It is signed by the super-admin who set it, and applies to the credentials recorded after it.
+type ApprovalPolicy struct {
+	Type      CredentialType `json:"type"`
+	Quorum    int            `json:"quorum"`
+	SignedBy  string         `json:"signed_by"`
+	SignedAt  time.Time      `json:"signed_at"`
+	Signature []byte         `json:"signature,omitempty"`
+}
+
+// signingHash hashes the policy without its signature.
+func (p *ApprovalPolicy) signingHash() ([]byte, error) {
+	unsigned := *p
+	unsigned.Signature = nil
+	data, err := json.Marshal(unsigned)
+	if err != nil {
+		return nil, err
+	}
+	hash := sha256.Sum256(data)
+	return hash[:], nil
+}
+
+// Proposal is a credential waiting in an IssuancePool for enough approvals to be committed.
+type Proposal struct {
+	Credential *Credential
+	Student    *Student
+	ProposedBy string
+	ProposedAt time.Time
+	ExpiresAt  time.Time
+	Quorum     int
+}
+
+// IssuancePool holds proposed credentials until a quorum of distinct admins has approved them.
+// The quorum of each credential type is the one recorded on the chain with SetApprovalQuorum.
+// A pool may be shared between goroutines when it commits through a LedgerService.
+type IssuancePool struct {
+	mu sync.Mutex
+	// update runs fn as the writer of the chain proposals are checked against and committed to
+	update    func(fn func(chain *CredentialChain) error) error
+	ttl       time.Duration
+	proposals map[string]*Proposal
+}
+
+// NewIssuancePool creates an issuance pool committing to chain whose proposals expire after ttl.
+// A chain shared through a LedgerService needs a pool from LedgerService.NewIssuancePool instead.
+func NewIssuancePool(chain *CredentialChain, ttl time.Duration) (*IssuancePool, error) {
+	if chain == nil {
+		return nil, fmt.Errorf("issuance pool needs a credential chain")
+	}
+	update := func(fn func(chain *CredentialChain) error) error {
+		return fn(chain)
+	}
+	return newIssuancePool(update, ttl)
+}
+
+func newIssuancePool(update func(fn func(chain *CredentialChain) error) error, ttl time.Duration) (*IssuancePool, error) {
+	if ttl <= 0 {
+		return nil, fmt.Errorf("proposal lifetime must be positive")
+	}
+	return &IssuancePool{update: update, ttl: ttl, proposals: make(map[string]*Proposal)}, nil
+}
+
+// Propose issues the credential to the student as the proposing admin and adds it to the pool.
+// The proposer's signature counts as the first approval. Credentials whose type needs no more than one
+// approval are committed straight away; the returned proposal then has no pending approvals.
+func (pool *IssuancePool) Propose(admin *Admin, s *Student, cred *Credential) (*Proposal, error) {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+
+	if err := admin.prepareCredential(s, cred); err != nil {
+		return nil, err
+	}
+	var quorum int
+	err := pool.update(func(chain *CredentialChain) error {
+		quorum = chain.quorum(cred.Type)
+		return chain.checkIssuerTrust(cred, time.Now())
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	proposal := &Proposal{
+		Credential: cred,
+		Student:    s,
+		ProposedBy: admin.AdminID,
+		ProposedAt: now,
+		ExpiresAt:  now.Add(pool.ttl),
+		Quorum:     quorum,
+	}
+	pool.proposals[cred.ID] = proposal
+
+	if _, err := pool.approve(admin, cred.ID); err != nil {
+		delete(pool.proposals, cred.ID)
+		return nil, err
+	}
+	return proposal, nil
+}
+
+// Approve records the admin's signed approval of the proposed credential. Once the quorum is reached the
+// credential is committed to the chain, added to the student's credentials and returned.
+// Until then Approve returns nil.
+func (pool *IssuancePool) Approve(admin *Admin, id string) (*Credential, error) {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+	return pool.approve(admin, id)
+}
+
+func (pool *IssuancePool) approve(admin *Admin, id string) (*Credential, error) {
+	pool.expireStale(time.Now())
+	proposal, ok := pool.proposals[id]
+	if !ok {
+		return nil, fmt.Errorf("no pending proposal for credential %s", id)
+	}
+	cred := proposal.Credential
+
+	// Each admin may approve once, and only admins who can sign for the issuer may approve
+	for _, approval := range cred.Approvals {
+		if approval.AdminID == admin.AdminID {
+			return nil, fmt.Errorf("admin %s has already approved credential %s", admin.AdminID, id)
+		}
+	}
+	if len(admin.PrivateKey) != ed25519.PrivateKeySize {
+		return nil, fmt.Errorf("admin %s has no signing key", admin.AdminID)
+	}
+
+	committed := false
+	err := pool.update(func(chain *CredentialChain) error {
+		key, err := chain.signerKey(cred, admin.AdminID)
+		if err != nil {
+			return err
+		}
+		approval := Approval{
+			AdminID:    admin.AdminID,
+			ApprovedAt: time.Now().UTC(),
+			Signature:  ed25519.Sign(admin.PrivateKey, approvalHash(cred)),
+		}
+		if !ed25519.Verify(key, approvalHash(cred), approval.Signature) {
+			return fmt.Errorf("admin %s signing key does not match its registered key", admin.AdminID)
+		}
+		cred.Approvals = append(cred.Approvals, approval)
+
+		// The quorum may have been changed on the chain since the credential was proposed
+		proposal.Quorum = chain.quorum(cred.Type)
+		if len(cred.Approvals) < proposal.Quorum {
+			return nil
+		}
+
+		// The quorum is reached, so the credential can be committed
+		if err := chain.AddCredentialModel(cred); err != nil {
+			return err
+		}
+		committed = true
+		return nil
+	})
+	if err != nil || !committed {
+		return nil, err
+	}
+	delete(pool.proposals, id)
+	proposal.Student.Credentials = append(proposal.Student.Credentials, cred)
+	return cred, nil
+}
+
+// Pending returns the proposals still waiting for approval, oldest first.
+func (pool *IssuancePool) Pending() []*Proposal {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+	pool.expireStale(time.Now())
+	pending := make([]*Proposal, 0, len(pool.proposals))
+	for _, proposal := range pool.proposals {
+		pending = append(pending, proposal)
+	}
+	sort.Slice(pending, func(i, j int) bool {
+		return pending[i].ProposedAt.Before(pending[j].ProposedAt)
+	})
+	return pending
+}
+
+// ExpireStale removes and returns the proposals that expired before the given time.
+func (pool *IssuancePool) ExpireStale(at time.Time) []*Proposal {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+	return pool.expireStale(at)
+}
+
+func (pool *IssuancePool) expireStale(at time.Time) []*Proposal {
+	var expired []*Proposal
+	for id, proposal := range pool.proposals {
+		if !at.Before(proposal.ExpiresAt) {
+			expired = append(expired, proposal)
+			delete(pool.proposals, id)
+		}
+	}
+	return expired
+}
+
+// SetApprovalQuorum records on the ledger that credentials of the given type need approvals from quorum
+// distinct admins. Credentials already on the chain keep the quorum they were recorded under.
+func (chain *CredentialChain) SetApprovalQuorum(by *Admin, credentialType CredentialType, quorum int) error {
+	if err := chain.checkSuperAdmin(by); err != nil {
+		return err
+	}
+	policy := &ApprovalPolicy{
+		Type:     credentialType,
+		Quorum:   quorum,
+		SignedBy: by.AdminID,
+		SignedAt: time.Now().UTC(),
+	}
+	hash, err := policy.signingHash()
+	if err != nil {
+		return err
+	}
+	policy.Signature = ed25519.Sign(by.PrivateKey, hash)
+	if err := chain.verifyApprovalPolicy(policy); err != nil {
+		return err
+	}
+
+	data, err := EncodePayload(PayloadApprovalPolicy, policy)
+	if err != nil {
+		return err
+	}
+	return chain.addBlockEntries([][]byte{data})
+}
+
+// verifyApprovalPolicy checks the policy's quorum and its signature against the registered super-admin keys.
+func (chain *CredentialChain) verifyApprovalPolicy(policy *ApprovalPolicy) error {
+	if policy.Type < Academic || policy.Type > Diploma {
+		return fmt.Errorf("unknown credential type %d", policy.Type)
+	}
+	if policy.Quorum < 1 {
+		return fmt.Errorf("approval quorum must be at least 1")
+	}
+	key, ok := chain.SuperAdminKeys[policy.SignedBy]
+	if !ok {
+		return fmt.Errorf("approval policy signed by unknown super-admin %q", policy.SignedBy)
+	}
+	hash, err := policy.signingHash()
+	if err != nil {
+		return err
+	}
+	if !ed25519.Verify(key, hash, policy.Signature) {
+		return fmt.Errorf("approval policy for %s credentials has an invalid signature", policy.Type)
+	}
+	return nil
+}
+
+// quorum returns the number of distinct approvals a credential of the given type needs to be added now.
+func (chain *CredentialChain) quorum(credentialType CredentialType) int {
+	return chain.quorumBefore(credentialType, chain.Height(), 0)
+}
+
+// quorumBefore returns the quorum set for the credential type by the last policy recorded before the given
+// entry of the given block, or 1 if there is none. Policies not signed by a registered super-admin are ignored.
+func (chain *CredentialChain) quorumBefore(credentialType CredentialType, blockIndex, entry int) int {
+	quorum := 1
+	for _, recorded := range chain.indexes().approvalPolicies {
+		if recorded.BlockIndex > blockIndex || (recorded.BlockIndex == blockIndex && recorded.Entry >= entry) {
+			break
+		}
+		if recorded.Policy.Type == credentialType && chain.verifyApprovalPolicy(recorded.Policy) == nil {
+			quorum = recorded.Policy.Quorum
+		}
+	}
+	return quorum
+}
+
+// checkApprovals checks that the credential carries valid approvals from at least quorum distinct signers of its issuer.
+func (chain *CredentialChain) checkApprovals(cred *Credential, quorum int) error {
+	if err := chain.verifyApprovals(cred); err != nil {
+		return err
+	}
+	if quorum > 1 && len(cred.Approvals) < quorum {
+		return fmt.Errorf("credential %s has %d of the %d approvals required for %s credentials", cred.ID, len(cred.Approvals), quorum, cred.Type)
+	}
+	return nil
+}
+
+// checkRecordedApprovals checks the credential's approvals against the quorum in force when it was recorded on the chain.
+func (chain *CredentialChain) checkRecordedApprovals(cred *Credential) error {
+	recorded, ok := chain.indexes().byID[cred.ID]
+	if !ok {
+		return fmt.Errorf("credential with ID %s not found", cred.ID)
+	}
+	return chain.checkApprovals(cred, chain.quorumBefore(cred.Type, recorded.BlockIndex, recorded.Entry))
+}
+
+// verifyApprovals checks the signature of every approval the credential carries and that no admin approved it twice.
+func (chain *CredentialChain) verifyApprovals(cred *Credential) error {
+	approvers := make(map[string]bool, len(cred.Approvals))
+	for _, approval := range cred.Approvals {
+		if approvers[approval.AdminID] {
+			return fmt.Errorf("admin %s approved credential %s more than once", approval.AdminID, cred.ID)
+		}
+		key, err := chain.signerKey(cred, approval.AdminID)
+		if err != nil {
+			return err
+		}
+		if !ed25519.Verify(key, approvalHash(cred), approval.Signature) {
+			return fmt.Errorf("approval of credential %s by admin %s has an invalid signature", cred.ID, approval.AdminID)
+		}
+		approvers[approval.AdminID] = true
+	}
+	return nil
+}
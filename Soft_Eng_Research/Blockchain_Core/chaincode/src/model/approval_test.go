@@ -0,0 +1,154 @@
+package model
+
+import (
+	"testing"
+	"time"
+)
+
+// newTestCredential returns an unsigned certificate of the test issuer, ready to be proposed.
+func newTestCredential() *Credential {
+	return &Credential{Type: Certificate, Issuer: testIssuerName, DateIssued: time.Now().Add(-time.Hour).UTC()}
+}
+
+// requireQuorum sets the quorum of certificates on the test ledger.
+func (l *testLedger) requireQuorum(t *testing.T, quorum int) {
+	t.Helper()
+	if err := l.chain.SetApprovalQuorum(l.superAdmin, Certificate, quorum); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestIssuancePoolQuorum(t *testing.T) {
+	l := newTestLedger(t)
+	l.requireQuorum(t, 2)
+	pool, err := NewIssuancePool(l.chain, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := testStudent(1)
+
+	proposal, err := pool.Propose(l.signer(), s, newTestCredential())
+	if err != nil {
+		t.Fatal(err)
+	}
+	id := proposal.Credential.ID
+	if proposal.Quorum != 2 || len(pool.Pending()) != 1 {
+		t.Fatalf("proposal has quorum %d with %d pending", proposal.Quorum, len(pool.Pending()))
+	}
+	if _, err := l.chain.FindCredentialByID(id); err == nil {
+		t.Fatal("credential was committed with one approval")
+	}
+
+	if _, err := pool.Approve(l.signer(), id); err == nil {
+		t.Error("the proposer approved twice")
+	}
+	if _, err := pool.Approve(l.superAdmin, id); err == nil {
+		t.Error("an admin who cannot sign for the issuer approved")
+	}
+
+	cred, err := pool.Approve(l.signers[1], id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cred == nil || len(cred.Approvals) != 2 || len(pool.Pending()) != 0 {
+		t.Fatalf("quorum reached but credential %v was not committed", cred)
+	}
+	if len(s.Credentials) != 1 {
+		t.Errorf("student holds %d credentials, want 1", len(s.Credentials))
+	}
+	report := l.chain.VerifyCredential(id)
+	expectStatus(t, report, StatusValid)
+	if !reportChecks(report)[CheckApprovals] {
+		t.Error("approvals check did not pass")
+	}
+}
+
+func TestQuorumIsEnforcedOnAdmission(t *testing.T) {
+	l := newTestLedger(t)
+	l.requireQuorum(t, 2)
+
+	// A credential signed by one admin is refused however it reaches the chain
+	if err := l.chain.AddCredentialModel(l.issue(t, testStudent(1), nil)); err == nil {
+		t.Error("a credential without a quorum of approvals was added")
+	}
+	entry := credentialEntry(t, l.issue(t, testStudent(1), nil))
+	if err := l.chain.AddBlockEntries([][]byte{entry}, time.Now()); err == nil {
+		t.Error("a block holding a credential without a quorum of approvals was added")
+	}
+
+	// Other credential types keep the default quorum of one
+	if err := l.chain.AddCredentialModel(l.issue(t, testStudent(1), func(cred *Credential) { cred.Type = Diploma })); err != nil {
+		t.Errorf("a diploma needing one approval was refused: %v", err)
+	}
+}
+
+func TestRaisedQuorumKeepsRecordedCredentialsValid(t *testing.T) {
+	l := newTestLedger(t)
+	cred := l.add(t, testStudent(1), nil)
+	l.requireQuorum(t, 3)
+	expectStatus(t, l.chain.VerifyCredential(cred.ID), StatusValid)
+}
+
+func TestSetApprovalQuorumRefusals(t *testing.T) {
+	l := newTestLedger(t)
+	if err := l.chain.SetApprovalQuorum(l.superAdmin, Certificate, 0); err == nil {
+		t.Error("a quorum of zero was set")
+	}
+	if err := l.chain.SetApprovalQuorum(l.signer(), Certificate, 2); err == nil {
+		t.Error("an admin who is not a super-admin set a quorum")
+	}
+	if q := l.chain.quorum(Certificate); q != 1 {
+		t.Fatalf("quorum is %d after refused policies, want 1", q)
+	}
+}
+
+func TestIssuancePoolExpiry(t *testing.T) {
+	l := newTestLedger(t)
+	l.requireQuorum(t, 2)
+	pool, err := NewIssuancePool(l.chain, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	proposal, err := pool.Propose(l.signer(), testStudent(1), newTestCredential())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if expired := pool.ExpireStale(time.Now()); len(expired) != 0 {
+		t.Fatalf("%d proposals expired early", len(expired))
+	}
+	expired := pool.ExpireStale(proposal.ExpiresAt)
+	if len(expired) != 1 || expired[0] != proposal {
+		t.Fatalf("expired proposals are %v", expired)
+	}
+	if _, err := pool.Approve(l.signers[1], proposal.Credential.ID); err == nil {
+		t.Fatal("an expired proposal was approved")
+	}
+	if _, err := NewIssuancePool(l.chain, 0); err == nil {
+		t.Fatal("a pool whose proposals never live was created")
+	}
+}
+
+func TestServiceIssuancePool(t *testing.T) {
+	l := newTestLedger(t)
+	l.requireQuorum(t, 2)
+	service := newTestService(t, l)
+	pool, err := service.NewIssuancePool(time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	proposal, err := pool.Propose(l.signer(), testStudent(1), newTestCredential())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := pool.Approve(l.signers[2], proposal.Credential.ID); err != nil {
+		t.Fatal(err)
+	}
+	err = service.View(func(view *LedgerView) error {
+		expectStatus(t, view.VerifyCredential(proposal.Credential.ID), StatusValid)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
@@ -3,23 +3,36 @@ package model
 import (
 	"bytes"
 	"crypto/sha256"
+	"encoding/binary"
 	"encoding/json"
 	"fmt"
 	"time"
 )
 
 // BlockChain structure contains a slice of blocks.
+// When the chain is backed by a LedgerStore, every appended block is written to disk.
+// Lookups go through secondary indexes that are kept up to date as blocks are appended.
+// A chain bootstrapped from a snapshot only holds the blocks after it; Blocks[0] then has the snapshot's height as its index.
 type BlockChain struct {
-	Blocks []Block
+	Blocks   []Block
+	store    *LedgerStore
+	index    *ledgerIndex
+	snapshot *Snapshot
 }
 
 // Block represents a block in the blockchain.
+// A block either carries a single payload in Data, or many payloads in Entries committed to by MerkleRoot.
+// Blocks of version 1 carry a typed Time; legacy blocks of version 0 carry an RFC3339 Timestamp instead.
 type Block struct {
-	Index     int
-	Timestamp string
-	Data      []byte
-	Hash      []byte
-	PrevHash  []byte
+	Version    int `json:",omitempty"`
+	Index      int
+	Timestamp  string    `json:",omitempty"`
+	Time       time.Time `json:",omitzero"`
+	Data       []byte
+	Entries    [][]byte `json:",omitempty"`
+	MerkleRoot []byte   `json:",omitempty"`
+	Hash       []byte
+	PrevHash   []byte
 }
 
 // Serialize serializes the block into a JSON byte slice.
@@ -27,11 +40,45 @@ func (b *Block) Serialize() ([]byte, error) {
 	return json.Marshal(b)
 }
 
-// DeriveHash generates a hash for the block using its index, timestamp, data, and previous hash.
-func (b *Block) DeriveHash() {
-	info := bytes.Join([][]byte{[]byte(fmt.Sprintf("%d", b.Index)), []byte(b.Timestamp), b.Data, b.PrevHash}, []byte{})
+// CurrentBlockVersion is the version of newly created blocks.
+const CurrentBlockVersion = 1
+
+// CalculateHash computes the hash of the block from its index, timestamp, data, Merkle root, and previous hash
+// without modifying the block. Entries are covered through the Merkle root.
+// Legacy blocks hash their fields as concatenated text; version 1 blocks hash the version, the index and
+// the time in nanoseconds as fixed-size integers, followed by each remaining field prefixed with its length.
+func (b *Block) CalculateHash() []byte {
+	if b.Version == 0 {
+		info := bytes.Join([][]byte{[]byte(fmt.Sprintf("%d", b.Index)), []byte(b.Timestamp), b.Data, b.MerkleRoot, b.PrevHash}, []byte{})
+		hash := sha256.Sum256(info)
+		return hash[:]
+	}
+
+	info := []byte{byte(b.Version)}
+	info = binary.BigEndian.AppendUint64(info, uint64(b.Index))
+	info = binary.BigEndian.AppendUint64(info, uint64(b.Time.UnixNano()))
+	for _, field := range [][]byte{b.Data, b.MerkleRoot, b.PrevHash} {
+		info = binary.BigEndian.AppendUint32(info, uint32(len(field)))
+		info = append(info, field...)
+	}
 	hash := sha256.Sum256(info)
-	b.Hash = hash[:]
+	return hash[:]
+}
+
+// CreatedAt returns when the block was created, parsing the text timestamp of legacy blocks.
+func (b *Block) CreatedAt() (time.Time, error) {
+	if b.Version == 0 {
+		return time.Parse(time.RFC3339, b.Timestamp)
+	}
+	if b.Time.IsZero() {
+		return time.Time{}, fmt.Errorf("block %d has no time", b.Index)
+	}
+	return b.Time, nil
+}
+
+// DeriveHash generates a hash for the block using its index, timestamp, data, Merkle root, and previous hash.
+func (b *Block) DeriveHash() {
+	b.Hash = b.CalculateHash()
 }
 
 // SetPrevHash sets the previous hash for the block.
@@ -47,56 +94,226 @@ func (b *Block) SetPrevHash(prevHash []byte) {
 // CreateBlock creates a new block with the given data and previous hash.
 func CreateBlock(index int, blockData []byte, prevHash []byte) *Block {
 	block := &Block{
-		Index:     index,
-		Timestamp: time.Now().Format(time.RFC3339),
-		Data:      blockData,
-		PrevHash:  prevHash,
+		Version:  CurrentBlockVersion,
+		Index:    index,
+		Time:     time.Now().UTC(),
+		Data:     blockData,
+		PrevHash: prevHash,
 	}
 	block.DeriveHash()
 	return block
 }
 
+// CreateEntriesBlock creates a new block holding several entries under a Merkle root.
+func CreateEntriesBlock(index int, entries [][]byte, prevHash []byte) *Block {
+	return newEntriesBlock(index, entries, prevHash, time.Now().UTC())
+}
+
+// newEntriesBlock creates a block holding several entries under a Merkle root, created at the given time.
+func newEntriesBlock(index int, entries [][]byte, prevHash []byte, at time.Time) *Block {
+	block := &Block{
+		Version:    CurrentBlockVersion,
+		Index:      index,
+		Time:       at,
+		Entries:    entries,
+		MerkleRoot: MerkleRoot(entries),
+		PrevHash:   prevHash,
+	}
+	block.DeriveHash()
+	return block
+}
+
+// Payloads returns the entries of the block, or its data when the block has no entries.
+func (b *Block) Payloads() [][]byte {
+	if len(b.Entries) > 0 {
+		return b.Entries
+	}
+	if len(b.Data) > 0 {
+		return [][]byte{b.Data}
+	}
+	return nil
+}
+
+// Header returns a copy of the block without its entries.
+// The header still hashes to the block hash, since the entries are covered by the Merkle root.
+func (b *Block) Header() Block {
+	header := *b
+	header.Entries = nil
+	header.Data = bytes.Clone(b.Data)
+	header.MerkleRoot = bytes.Clone(b.MerkleRoot)
+	header.Hash = bytes.Clone(b.Hash)
+	header.PrevHash = bytes.Clone(b.PrevHash)
+	return header
+}
+
+// clone returns a deep copy of the block, entries included, that shares no memory with it.
+func (b *Block) clone() Block {
+	copied := b.Header()
+	if b.Entries != nil {
+		copied.Entries = make([][]byte, len(b.Entries))
+		for i, entry := range b.Entries {
+			copied.Entries[i] = bytes.Clone(entry)
+		}
+	}
+	return copied
+}
+
 // AddBlock adds a new block to the blockchain.
-func (chain *BlockChain) AddBlock(blockData []byte) {
-	if len(chain.Blocks) == 0 {
+// The block is persisted before it becomes part of the in-memory chain.
+// Data recording a ledger event is refused; such entries must go through CredentialChain.AddBlockEntries, which checks them.
+func (chain *BlockChain) AddBlock(blockData []byte) error {
+	if envelope, _, err := DecodePayload(blockData); err == nil {
+		return fmt.Errorf("%s entries must be added through a credential chain", envelope.Type)
+	}
+	return chain.addBlock(func(index int, prevHash []byte) *Block {
+		return CreateBlock(index, blockData, prevHash)
+	})
+}
+
+// addBlockEntries adds a new block created now holding all of the given entries under one Merkle root, without checking them.
+func (chain *BlockChain) addBlockEntries(entries [][]byte) error {
+	return chain.addBlockEntriesAt(entries, time.Now().UTC())
+}
+
+// addBlockEntriesAt is addBlockEntries for a block created at the given time.
+func (chain *BlockChain) addBlockEntriesAt(entries [][]byte, at time.Time) error {
+	if len(entries) == 0 {
+		return fmt.Errorf("block must have at least one entry")
+	}
+	return chain.addBlock(func(index int, prevHash []byte) *Block {
+		return newEntriesBlock(index, entries, prevHash, at)
+	})
+}
+
+// addBlock links the block built by create to the end of the chain and appends it.
+func (chain *BlockChain) addBlock(create func(index int, prevHash []byte) *Block) error {
+	if len(chain.Blocks) == 0 && chain.snapshot == nil {
 		fmt.Println("Blockchain is empty, adding Genesis block first.")
-		genesisBlock := Genesis()
-		chain.Blocks = append(chain.Blocks, *genesisBlock)
+		if err := chain.appendBlock(Genesis()); err != nil {
+			return err
+		}
 	}
 
-	prevBlock := chain.Blocks[len(chain.Blocks)-1]
+	prevBlock := chain.tip()
 
-	// Validate the previous block's hash
-	prevBlock.DeriveHash()
-	if !bytes.Equal(prevBlock.Hash, prevBlock.Hash) {
-		fmt.Println("Previous block's hash is invalid.")
-		return
+	// Validate the previous block's hash; the snapshot's last block is only known by its hash
+	if len(chain.Blocks) > 0 && !bytes.Equal(prevBlock.Hash, prevBlock.CalculateHash()) {
+		return fmt.Errorf("previous block %d hash is invalid", prevBlock.Index)
 	}
 
 	newIndex := prevBlock.Index + 1
-	newBlock := CreateBlock(newIndex, blockData, prevBlock.Hash)
-	chain.Blocks = append(chain.Blocks, *newBlock)
+	newBlock := create(newIndex, prevBlock.Hash)
+
+	// Block times must never go backwards, even if the clock does
+	prevTime, err := prevBlock.CreatedAt()
+	if err != nil {
+		return fmt.Errorf("previous block %d has an invalid timestamp: %w", prevBlock.Index, err)
+	}
+	if newBlock.Time.Before(prevTime) {
+		return fmt.Errorf("block %d time %s is before block %d time %s", newIndex, newBlock.Time.Format(time.RFC3339Nano), prevBlock.Index, prevTime.Format(time.RFC3339Nano))
+	}
+	return chain.appendBlock(newBlock)
+}
+
+// tip returns the last block of the chain, or the header of the snapshot's last block when the chain
+// has no blocks after its snapshot.
+func (chain *BlockChain) tip() *Block {
+	if len(chain.Blocks) > 0 {
+		return &chain.Blocks[len(chain.Blocks)-1]
+	}
+	return chain.snapshot.anchor()
+}
+
+// Height returns the number of blocks in the chain, including those covered by the snapshot it was bootstrapped from.
+func (chain *BlockChain) Height() int {
+	return chain.snapshot.height() + len(chain.Blocks)
+}
+
+// block returns the block with the given index, or nil if it is not held by the chain.
+func (chain *BlockChain) block(index int) *Block {
+	i := index - chain.snapshot.height()
+	if i < 0 || i >= len(chain.Blocks) {
+		return nil
+	}
+	return &chain.Blocks[i]
+}
+
+// appendBlock writes the block to the ledger store, if any, and appends it to the chain.
+func (chain *BlockChain) appendBlock(block *Block) error {
+	if chain.store != nil {
+		if err := chain.store.Append(block); err != nil {
+			return fmt.Errorf("failed to persist block %d: %w", block.Index, err)
+		}
+	}
+	chain.Blocks = append(chain.Blocks, *block)
+	chain.indexes()
+	return nil
 }
 
 // Genesis creates the first block in the blockchain.
 func Genesis() *Block {
-	return CreateBlock(0, []byte("Genesis Block"), []byte{})
+	data, err := EncodePayload(PayloadGenesis, GenesisPayload{Message: legacyGenesisData})
+	if err != nil {
+		// The genesis payload is a fixed, registered type, so encoding it cannot fail
+		panic(err)
+	}
+	return CreateBlock(0, data, []byte{})
 }
 
 // NewBlockChain creates a blockchain with the genesis block.
-func NewBlockChain() *BlockChain {
-	return &BlockChain{Blocks: []Block{*Genesis()}}
+// If store is nil the chain only lives in memory. If store already holds blocks the chain is
+// reopened from them, and every block's hash and link to its predecessor is verified first.
+func NewBlockChain(store *LedgerStore) (*BlockChain, error) {
+	chain := &BlockChain{store: store}
+
+	if store == nil || store.Len() == 0 {
+		if err := chain.appendBlock(Genesis()); err != nil {
+			return nil, err
+		}
+		return chain, nil
+	}
+
+	blocks, err := store.LoadBlocks()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load ledger: %w", err)
+	}
+	if err := ValidateBlocks(blocks).Err(); err != nil {
+		return nil, fmt.Errorf("ledger is corrupt: %w", err)
+	}
+
+	chain.Blocks = blocks
+	chain.RebuildIndex()
+	return chain, nil
 }
 
 // FindCredentialByID searches the blockchain for a credential with the given ID.
 func (chain *BlockChain) FindCredentialByID(id string) (*Credential, error) {
-	for _, block := range chain.Blocks {
-		var cred Credential
-		if err := json.Unmarshal(block.Data, &cred); err == nil {
-			if cred.ID == id {
-				return &cred, nil
-			}
-		}
+	cred, _, _, err := chain.locateCredential(id)
+	return cred, err
+}
+
+// locateCredential finds a credential along with the block and entry position that hold it.
+// The block is nil for credentials recorded before the snapshot the chain was bootstrapped from.
+func (chain *BlockChain) locateCredential(id string) (*Credential, *Block, int, error) {
+	entry, ok := chain.indexes().byID[id]
+	if !ok {
+		return nil, nil, 0, fmt.Errorf("credential with ID %s not found", id)
+	}
+	return entry.Credential.clone(), chain.block(entry.BlockIndex), entry.Entry, nil
+}
+
+// ProveInclusion builds an inclusion proof for the entry at position entry of block.
+func (chain *BlockChain) ProveInclusion(block *Block, entry int) (*InclusionProof, error) {
+	if len(block.Entries) == 0 {
+		return nil, fmt.Errorf("block %d has no Merkle entries", block.Index)
+	}
+	proof, err := BuildMerkleProof(block.Entries, entry)
+	if err != nil {
+		return nil, err
 	}
-	return nil, fmt.Errorf("credential with ID %s not found", id)
+	return &InclusionProof{
+		Entry:  bytes.Clone(block.Entries[entry]),
+		Header: block.Header(),
+		Proof:  *proof,
+	}, nil
 }
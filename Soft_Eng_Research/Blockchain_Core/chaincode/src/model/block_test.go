@@ -0,0 +1,94 @@
+package model
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestBlockJSONTime(t *testing.T) {
+	legacy := legacyBlock()
+	data, err := legacy.Serialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(data, []byte(`"Time"`)) {
+		t.Fatalf("legacy block JSON %s has a Time", data)
+	}
+	var decoded Block
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(decoded.CalculateHash(), legacy.Hash) {
+		t.Fatal("legacy block hash changed in a JSON round trip")
+	}
+
+	current := CreateEntriesBlock(1, merkleEntries(2), legacy.Hash)
+	data, err = current.Serialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Contains(data, []byte(`"Time"`)) || bytes.Contains(data, []byte(`"Timestamp"`)) {
+		t.Fatalf("version %d block JSON %s should carry Time and no Timestamp", current.Version, data)
+	}
+}
+
+func TestBlockTimeNeverGoesBackwards(t *testing.T) {
+	chain, err := NewBlockChain(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	at := time.Now().Add(time.Minute).UTC()
+	if err := chain.addBlockEntriesAt(merkleEntries(1), at); err != nil {
+		t.Fatal(err)
+	}
+	if err := chain.addBlockEntriesAt(merkleEntries(1), at.Add(-time.Nanosecond)); err == nil {
+		t.Fatal("a block dated before the previous block was added")
+	}
+	if err := chain.addBlockEntriesAt(merkleEntries(1), at); err != nil {
+		t.Fatalf("a block with the same time as the previous block was refused: %v", err)
+	}
+	if err := chain.Validate().Err(); err != nil {
+		t.Fatalf("chain is invalid: %v", err)
+	}
+}
+
+func TestFindCredentialsByTime(t *testing.T) {
+	l := newTestLedger(t)
+	base := time.Now().Add(time.Minute)
+	var ids []string
+	for i, issued := range []time.Time{
+		time.Date(2024, 1, 10, 0, 0, 0, 0, time.UTC),
+		time.Date(2024, 3, 10, 0, 0, 0, 0, time.UTC),
+		time.Date(2024, 5, 10, 0, 0, 0, 0, time.UTC),
+	} {
+		cred := signedCredential(t, l.signer(), testIssuerName, issued)
+		if err := l.chain.AddBlockEntries([][]byte{credentialEntry(t, cred)}, base.Add(time.Duration(i)*time.Hour)); err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, cred.ID)
+	}
+
+	sameIDs := func(creds []*Credential, want ...string) bool {
+		if len(creds) != len(want) {
+			return false
+		}
+		for i := range creds {
+			if creds[i].ID != want[i] {
+				return false
+			}
+		}
+		return true
+	}
+
+	issued := l.chain.FindCredentialsIssuedBetween(time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC))
+	if !sameIDs(issued, ids[1], ids[2]) {
+		t.Errorf("credentials issued from February to May are %v, want %v", issued, ids[1:])
+	}
+	// The end of the range is excluded
+	recorded := l.chain.FindCredentialsRecordedBetween(base, base.Add(2*time.Hour))
+	if !sameIDs(recorded, ids[0], ids[1]) {
+		t.Errorf("credentials recorded in the first two hours are %v, want %v", recorded, ids[:2])
+	}
+}
@@ -2,11 +2,19 @@ package model
 
 import (
 	"bytes"
-	"encoding/json"
+	"crypto/ed25519"
+	"errors"
 	"fmt"
 	"time"
 )
 
+var (
+	// ErrCredentialExpired is returned when verifying a credential after its expiry time.
+	ErrCredentialExpired = errors.New("credential has expired")
+	// ErrCredentialNotYetValid is returned when verifying a credential before its validity window starts.
+	ErrCredentialNotYetValid = errors.New("credential is not yet valid")
+)
+
 // CredentialType enumeration
 type CredentialType int
 
@@ -17,59 +25,195 @@ const (
 	Diploma
 )
 
+// String returns the name of the credential type, or its number for a type this package does not know,
+// such as one read from a tampered or newer ledger.
 func (ct CredentialType) String() string {
+	if ct < Academic || ct > Diploma {
+		return fmt.Sprintf("CredentialType(%d)", int(ct))
+	}
 	return [...]string{"Academic", "NonAcademic", "Certificate", "Diploma"}[ct]
 }
 
+// ParseCredentialType returns the credential type with the given name.
+func ParseCredentialType(name string) (CredentialType, error) {
+	for ct := Academic; ct <= Diploma; ct++ {
+		if ct.String() == name {
+			return ct, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown credential type %q", name)
+}
+
 // Credential represents an individual credential.
 type Credential struct {
-	ID         string         `json:"id"`
-	Type       CredentialType `json:"type"`
-	Issuer     string         `json:"issuer"`
-	DateIssued time.Time      `json:"date_issued"`
-	Hash       []byte         `json:"hash"`
-	Status     string         `json:"status"`
+	ID         string          `json:"id"`
+	OwnerID    int             `json:"owner_id,omitempty"`
+	Type       CredentialType  `json:"type"`
+	Issuer     string          `json:"issuer"`
+	DateIssued time.Time       `json:"date_issued"`
+	ValidFrom  *time.Time      `json:"valid_from,omitempty"`
+	ExpiresAt  *time.Time      `json:"expires_at,omitempty"`
+	Document   *DocumentDigest `json:"document,omitempty"`
+	// Commitments holds a salted hash of each claim the holder can selectively disclose
+	Commitments map[string][]byte `json:"commitments,omitempty"`
+	// Supersedes is the ID of the previous version of the credential, if this one replaces it
+	Supersedes string `json:"supersedes,omitempty"`
+	// HashVersion is the serialization format Hash was computed over; see GenerateCredentialHash
+	HashVersion int    `json:"hash_version,omitempty"`
+	Hash        []byte `json:"hash"`
+	Status      string `json:"status"`
+	SignerID    string `json:"signer_id,omitempty"`
+	Signature   []byte `json:"signature,omitempty"`
+	// Approvals are the admins' sign-offs on the credential hash, collected by an IssuancePool
+	Approvals []Approval `json:"approvals,omitempty"`
+}
+
+// clone returns a deep copy of the credential that shares no memory with it.
+func (cred *Credential) clone() *Credential {
+	copied := *cred
+	copied.ValidFrom = cloneTime(cred.ValidFrom)
+	copied.ExpiresAt = cloneTime(cred.ExpiresAt)
+	if cred.Document != nil {
+		document := *cred.Document
+		document.SHA256 = bytes.Clone(cred.Document.SHA256)
+		copied.Document = &document
+	}
+	if cred.Commitments != nil {
+		copied.Commitments = make(map[string][]byte, len(cred.Commitments))
+		for claim, commitment := range cred.Commitments {
+			copied.Commitments[claim] = bytes.Clone(commitment)
+		}
+	}
+	copied.Hash = bytes.Clone(cred.Hash)
+	copied.Signature = bytes.Clone(cred.Signature)
+	if cred.Approvals != nil {
+		copied.Approvals = make([]Approval, len(cred.Approvals))
+		for i, approval := range cred.Approvals {
+			approval.Signature = bytes.Clone(approval.Signature)
+			copied.Approvals[i] = approval
+		}
+	}
+	return &copied
 }
 
-// ValidateCredentialData ensures the credential fields are valid.
+// ValidateCredentialData ensures the credential fields are valid as of now.
 func ValidateCredentialData(cred *Credential) error {
+	return validateCredentialDataAt(cred, time.Now())
+}
+
+// validateCredentialDataAt ensures the credential fields are valid, judging its issue date and expiry as of the given time.
+func validateCredentialDataAt(cred *Credential, at time.Time) error {
 	if cred.Type.String() == "" {
 		return fmt.Errorf("credential type cannot be empty")
 	}
 	if cred.Issuer == "" {
 		return fmt.Errorf("issuer cannot be empty")
 	}
-	if cred.DateIssued.After(time.Now()) {
+	if cred.HashVersion != LegacyCredentialHashVersion && cred.HashVersion != CredentialHashVersion {
+		return fmt.Errorf("unknown credential hash version %d", cred.HashVersion)
+	}
+	if cred.DateIssued.After(at) {
 		return fmt.Errorf("issued date cannot be in the future")
 	}
+	if cred.ExpiresAt != nil {
+		if !cred.ExpiresAt.After(cred.DateIssued) {
+			return fmt.Errorf("expiry date must be after the issued date")
+		}
+		if cred.ValidFrom != nil && !cred.ExpiresAt.After(*cred.ValidFrom) {
+			return fmt.Errorf("expiry date must be after the start of the validity window")
+		}
+		if !cred.ExpiresAt.After(at) {
+			return fmt.Errorf("credential has already expired")
+		}
+	}
+	return nil
+}
+
+// CheckValidity reports whether the credential is within its validity window at the given time.
+// A credential without a window is always valid.
+func (cred *Credential) CheckValidity(at time.Time) error {
+	if cred.ValidFrom != nil && at.Before(*cred.ValidFrom) {
+		return fmt.Errorf("credential %s is valid from %s: %w", cred.ID, cred.ValidFrom.Format(time.RFC3339), ErrCredentialNotYetValid)
+	}
+	if cred.ExpiresAt != nil && !at.Before(*cred.ExpiresAt) {
+		return fmt.Errorf("credential %s expired on %s: %w", cred.ID, cred.ExpiresAt.Format(time.RFC3339), ErrCredentialExpired)
+	}
 	return nil
 }
 
 // CredentialChain is an alias for BlockChain, which stores credentials.
+// SuperAdminKeys holds the keys trusted to sign changes to the issuer registry recorded on the chain.
 type CredentialChain struct {
 	BlockChain
+	SuperAdminKeys map[string]ed25519.PublicKey
+
+	// issuers caches the issuer registry built from the first issuersEvents issuer events
+	issuers       map[string]*TrustedIssuer
+	issuersEvents int
+}
+
+// NewCredentialChain creates a credential chain, reopening it from store when one is given.
+func NewCredentialChain(store *LedgerStore) (*CredentialChain, error) {
+	chain, err := NewBlockChain(store)
+	if err != nil {
+		return nil, err
+	}
+	return &CredentialChain{BlockChain: *chain}, nil
 }
 
 // AddCredential adds a new credential to the blockchain.
 func (chain *CredentialChain) AddCredentialModel(cred *Credential) error {
-	if err := ValidateCredentialData(cred); err != nil {
-		return err
+	return chain.AddCredentialBatch([]*Credential{cred})
+}
+
+// AddCredentialBatch adds several credentials to the blockchain in a single block.
+// Each credential becomes one leaf of the block's Merkle tree. A credential that is not signed by its issuer,
+// whose ID is already on the chain, or whose issuer is unknown or suspended, is rejected.
+func (chain *CredentialChain) AddCredentialBatch(creds []*Credential) error {
+	if len(creds) == 0 {
+		return fmt.Errorf("no credentials to add")
 	}
-	cred.Hash = GenerateCredentialHash(cred)
-	credData, err := json.Marshal(cred)
-	if err != nil {
-		return err
+
+	entries := make([][]byte, 0, len(creds))
+	block := newBlockAdmission(time.Now().UTC())
+	for _, cred := range creds {
+		if err := chain.admitCredential(cred, block); err != nil {
+			return err
+		}
+		credData, err := EncodePayload(PayloadCredentialIssued, cred)
+		if err != nil {
+			return err
+		}
+		entries = append(entries, credData)
+	}
+	return chain.addBlockEntriesAt(entries, block.at)
+}
+
+// checkCredentialID checks that the credential has an ID that is not already used. The ID is covered by the
+// issuer signature, so it is assigned when the credential is signed, never on admission.
+func (chain *CredentialChain) checkCredentialID(cred *Credential) error {
+	if cred.ID == "" {
+		return fmt.Errorf("signed credential has no ID")
+	}
+	if _, exists := chain.indexes().byID[cred.ID]; exists {
+		return fmt.Errorf("credential with ID %s already exists", cred.ID)
 	}
-	chain.AddBlock(credData)
 	return nil
 }
 
-// VerifyCredential checks if a credential exists in the blockchain.
-func (chain *CredentialChain) VerifyCredential(id string) (bool, error) {
-	cred, err := chain.FindCredentialByID(id)
+// ProveCredential returns a proof that the credential with the given ID is included in the chain.
+func (chain *CredentialChain) ProveCredential(id string) (*InclusionProof, error) {
+	_, block, entry, err := chain.locateCredential(id)
+	if err != nil {
+		return nil, err
+	}
+	if block == nil {
+		return nil, fmt.Errorf("credential %s was recorded before the chain's snapshot, which holds no blocks to prove it", id)
+	}
+	proof, err := chain.ProveInclusion(block, entry)
 	if err != nil {
-		return false, err
+		return nil, err
 	}
-	expectedHash := GenerateCredentialHash(cred)
-	return bytes.Equal(cred.Hash, expectedHash), nil
+	proof.CredentialID = id
+	return proof, nil
 }
@@ -0,0 +1,89 @@
+package model
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestValidateCredentialDataWindow(t *testing.T) {
+	now := time.Now()
+	before, after := now.Add(-time.Hour), now.Add(time.Hour)
+	tests := map[string]struct {
+		issued, validFrom, expires *time.Time
+		ok                         bool
+	}{
+		"no window":                 {issued: &before, ok: true},
+		"open window":               {issued: &before, validFrom: &after, expires: ptrTime(now.Add(2 * time.Hour)), ok: true},
+		"issued in the future":      {issued: &after},
+		"expires before issue":      {issued: &before, expires: ptrTime(before.Add(-time.Minute))},
+		"expires before valid from": {issued: &before, validFrom: ptrTime(now.Add(2 * time.Hour)), expires: &after},
+		"already expired":           {issued: ptrTime(now.Add(-2 * time.Hour)), expires: &before},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			cred := &Credential{Type: Certificate, Issuer: testIssuerName, DateIssued: *tt.issued, ValidFrom: tt.validFrom, ExpiresAt: tt.expires, HashVersion: CredentialHashVersion}
+			err := ValidateCredentialData(cred)
+			if tt.ok && err != nil {
+				t.Fatalf("valid credential was refused: %v", err)
+			}
+			if !tt.ok && err == nil {
+				t.Fatal("invalid credential was accepted")
+			}
+		})
+	}
+}
+
+func TestCheckValidity(t *testing.T) {
+	from := time.Date(2025, 9, 1, 0, 0, 0, 0, time.UTC)
+	until := time.Date(2026, 9, 1, 0, 0, 0, 0, time.UTC)
+	cred := &Credential{ID: "c", ValidFrom: &from, ExpiresAt: &until}
+
+	if err := cred.CheckValidity(from.Add(-time.Second)); !errors.Is(err, ErrCredentialNotYetValid) {
+		t.Errorf("before the window: got %v, want %v", err, ErrCredentialNotYetValid)
+	}
+	if err := cred.CheckValidity(from); err != nil {
+		t.Errorf("start of the window: %v", err)
+	}
+	if err := cred.CheckValidity(until); !errors.Is(err, ErrCredentialExpired) {
+		t.Errorf("end of the window: got %v, want %v", err, ErrCredentialExpired)
+	}
+	if err := (&Credential{}).CheckValidity(until); err != nil {
+		t.Errorf("credential without a window: %v", err)
+	}
+}
+
+func TestVerifyCredentialReportsValidityWindow(t *testing.T) {
+	l := newTestLedger(t)
+	validFrom := time.Now().Add(time.Hour)
+	expires := time.Now().Add(24 * time.Hour)
+	cred := l.add(t, testStudent(1), func(cred *Credential) {
+		cred.ValidFrom = &validFrom
+		cred.ExpiresAt = &expires
+	})
+
+	tests := []struct {
+		at   time.Time
+		want VerificationStatus
+		err  error
+	}{
+		{time.Now(), StatusNotYetValid, ErrCredentialNotYetValid},
+		{validFrom, StatusValid, nil},
+		{expires, StatusExpired, ErrCredentialExpired},
+	}
+	for _, tt := range tests {
+		report := l.chain.VerifyCredentialAt(cred.ID, tt.at)
+		expectStatus(t, report, tt.want)
+		if err := report.Err(); !errors.Is(err, tt.err) {
+			t.Errorf("at %s Err returned %v, want %v", tt.at, err, tt.err)
+		}
+		// Being outside the window is not the same as being invalid; only the expiry check fails
+		if failed := report.Failed(); tt.err != nil && (len(failed) != 1 || failed[0].Check != CheckExpiry) {
+			t.Errorf("at %s failed checks are %+v, want only %s", tt.at, failed, CheckExpiry)
+		}
+	}
+}
+
+func ptrTime(at time.Time) *time.Time {
+	return &at
+}
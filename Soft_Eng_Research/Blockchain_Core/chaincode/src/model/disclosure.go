@@ -0,0 +1,155 @@
+package model
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// claimSaltSize is the number of random bytes mixed into each claim commitment.
+const claimSaltSize = 16
+
+// claimNamePattern restricts claim names so they cannot collide in the serialized commitments.
+var claimNamePattern = regexp.MustCompile(`^[a-z0-9_]+$`)
+
+// Disclosure reveals one committed claim of a credential: its value and the salt it was committed with.
+// The holder keeps the disclosures and shares only the ones they choose.
+type Disclosure struct {
+	Claim string `json:"claim"`
+	Value string `json:"value"`
+	Salt  []byte `json:"salt"`
+}
+
+// Commitment returns the salted hash that binds the disclosure's claim to its value.
+func (d *Disclosure) Commitment() []byte {
+	data := make([]byte, 0, len(d.Salt)+len(d.Claim)+len(d.Value)+1)
+	data = append(data, d.Salt...)
+	data = append(data, d.Claim...)
+	data = append(data, 0)
+	data = append(data, d.Value...)
+	hash := sha256.Sum256(data)
+	return hash[:]
+}
+
+// Presentation is what a holder shares with a verifier: a credential ID and the claims they chose to disclose.
+type Presentation struct {
+	CredentialID string       `json:"credential_id"`
+	Disclosures  []Disclosure `json:"disclosures"`
+}
+
+// ledgerClaims are the details recorded in clear on the ledger, such as the credential's owner.
+// Committing them would hide nothing, so they cannot be disclosed selectively.
+var ledgerClaims = map[string]bool{
+	"owner_id": true,
+}
+
+// StandardClaims returns the claims usually committed for a credential issued to the student,
+// whose details must be known. Callers can add credential specific claims, such as a degree, before committing them.
+func StandardClaims(cred *Credential, s *Student) map[string]string {
+	claims := StudentClaims(s)
+	claims["issuer"] = cred.Issuer
+	claims["type"] = cred.Type.String()
+	claims["date_issued"] = cred.DateIssued.Format(time.RFC3339)
+	return claims
+}
+
+// CommitClaims stores a salted hash commitment for each claim on the credential and returns the
+// disclosures the holder needs to reveal them later. It must be called before the credential is signed,
+// since the commitments are covered by the credential hash.
+func (cred *Credential) CommitClaims(claims map[string]string) (map[string]Disclosure, error) {
+	for claim := range claims {
+		if ledgerClaims[claim] {
+			return nil, fmt.Errorf("claim %q is recorded on the ledger in clear and cannot be disclosed selectively", claim)
+		}
+	}
+	commitments, disclosures, err := commitClaims(claims)
+	if err != nil {
+		return nil, err
+	}
+	cred.Commitments = commitments
+	return disclosures, nil
+}
+
+// commitClaims returns a salted hash commitment for each claim, and the disclosures that reveal them.
+func commitClaims(claims map[string]string) (map[string][]byte, map[string]Disclosure, error) {
+	commitments := make(map[string][]byte, len(claims))
+	disclosures := make(map[string]Disclosure, len(claims))
+
+	for claim, value := range claims {
+		if !claimNamePattern.MatchString(claim) {
+			return nil, nil, fmt.Errorf("invalid claim name %q", claim)
+		}
+		salt := make([]byte, claimSaltSize)
+		if _, err := rand.Read(salt); err != nil {
+			return nil, nil, fmt.Errorf("failed to generate salt: %w", err)
+		}
+		disclosure := Disclosure{Claim: claim, Value: value, Salt: salt}
+		commitments[claim] = disclosure.Commitment()
+		disclosures[claim] = disclosure
+	}
+	return commitments, disclosures, nil
+}
+
+// serializeCommitments formats the commitments in claim order as they are covered by the credential hash.
+func serializeCommitments(commitments map[string][]byte) string {
+	claims := make([]string, 0, len(commitments))
+	for claim := range commitments {
+		claims = append(claims, claim)
+	}
+	sort.Strings(claims)
+
+	parts := make([]string, 0, len(claims))
+	for _, claim := range claims {
+		parts = append(parts, claim+":"+hex.EncodeToString(commitments[claim]))
+	}
+	return strings.Join(parts, ",")
+}
+
+// VerifyPresentation verifies the presented credential and checks every disclosed claim against
+// the commitments recorded on the chain. The report holds the disclosed values, but not the credential,
+// so the verifier learns only what the holder chose to reveal.
+func (chain *CredentialChain) VerifyPresentation(p *Presentation) *VerificationReport {
+	report := chain.VerifyCredentialAt(p.CredentialID, time.Now())
+	if report.Status == StatusNotFound {
+		return report
+	}
+	cred := report.Credential
+	report.Credential = nil
+
+	disclosed, err := checkDisclosures(cred.Commitments, p.Disclosures)
+	if err != nil {
+		report.add(CheckDisclosure, err)
+		report.Status = StatusInvalid
+		report.Valid = false
+		return report
+	}
+	report.add(CheckDisclosure, nil)
+	report.Disclosed = disclosed
+	return report
+}
+
+// checkDisclosures checks each disclosure against the commitment to its claim and returns the disclosed values.
+func checkDisclosures(commitments map[string][]byte, disclosures []Disclosure) (map[string]string, error) {
+	if len(disclosures) == 0 {
+		return nil, fmt.Errorf("no claims are disclosed")
+	}
+
+	disclosed := make(map[string]string, len(disclosures))
+	for _, d := range disclosures {
+		commitment, ok := commitments[d.Claim]
+		if !ok {
+			return nil, fmt.Errorf("no commitment for claim %q", d.Claim)
+		}
+		if !bytes.Equal(commitment, d.Commitment()) {
+			return nil, fmt.Errorf("disclosed value of claim %q does not match its commitment", d.Claim)
+		}
+		disclosed[d.Claim] = d.Value
+	}
+	return disclosed, nil
+}
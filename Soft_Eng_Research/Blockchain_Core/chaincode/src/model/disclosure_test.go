@@ -0,0 +1,108 @@
+package model
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// addCommitted adds a credential for the student committing to its standard claims and a degree,
+// and returns it with the disclosures the holder keeps.
+func (l *testLedger) addCommitted(t *testing.T, s *Student) (*Credential, map[string]Disclosure) {
+	t.Helper()
+	var disclosures map[string]Disclosure
+	cred := l.add(t, s, func(cred *Credential) {
+		claims := StandardClaims(cred, s)
+		claims["degree"] = "BSc Computer Science"
+		var err error
+		if disclosures, err = cred.CommitClaims(claims); err != nil {
+			t.Fatal(err)
+		}
+	})
+	return cred, disclosures
+}
+
+func TestPresentationRevealsOnlyDisclosedClaims(t *testing.T) {
+	l := newTestLedger(t)
+	s := testStudent(1)
+	cred, disclosures := l.addCommitted(t, s)
+
+	p := &Presentation{CredentialID: cred.ID, Disclosures: []Disclosure{disclosures["degree"], disclosures["birth_date"]}}
+	report := l.chain.VerifyPresentation(p)
+	expectStatus(t, report, StatusValid)
+	if len(report.Disclosed) != 2 || report.Disclosed["degree"] != "BSc Computer Science" || report.Disclosed["birth_date"] != "2001-12-10" {
+		t.Fatalf("presentation disclosed %v", report.Disclosed)
+	}
+	if report.Credential != nil {
+		t.Fatal("presentation report holds the whole credential")
+	}
+	data, err := json.Marshal(report)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, hidden := range []string{s.LastName, "202400001", "commitments"} {
+		if strings.Contains(string(data), hidden) {
+			t.Errorf("presentation report %s reveals %q", data, hidden)
+		}
+	}
+}
+
+func TestPresentationRejectsBadDisclosures(t *testing.T) {
+	l := newTestLedger(t)
+	cred, disclosures := l.addCommitted(t, testStudent(1))
+
+	changed := disclosures["student_number"]
+	changed.Value = "202499999"
+	tests := map[string][]Disclosure{
+		"changed value":     {changed},
+		"unknown claim":     {{Claim: "honours", Value: "first class", Salt: make([]byte, claimSaltSize)}},
+		"nothing disclosed": nil,
+	}
+	for name, presented := range tests {
+		t.Run(name, func(t *testing.T) {
+			report := l.chain.VerifyPresentation(&Presentation{CredentialID: cred.ID, Disclosures: presented})
+			expectStatus(t, report, StatusInvalid)
+			if report.Disclosed != nil {
+				t.Fatalf("rejected presentation disclosed %v", report.Disclosed)
+			}
+		})
+	}
+}
+
+func TestCommitClaimsRefusesLedgerClaims(t *testing.T) {
+	cred := &Credential{}
+	if _, err := cred.CommitClaims(map[string]string{"owner_id": "1"}); err == nil {
+		t.Fatal("the owner, recorded in clear, was committed as a claim")
+	}
+	if _, err := cred.CommitClaims(map[string]string{"Bad Name": "x"}); err == nil {
+		t.Fatal("an invalid claim name was committed")
+	}
+}
+
+func TestExpiredPresentationErr(t *testing.T) {
+	l := newTestLedger(t)
+	expired := time.Now().Add(-time.Hour)
+	cred := &Credential{OwnerID: 1, Type: Certificate, Issuer: testIssuerName, DateIssued: expired.Add(-time.Hour), ExpiresAt: &expired}
+	disclosures, err := cred.CommitClaims(map[string]string{"degree": "BSc"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cred.ID, err = NewCredentialID(); err != nil {
+		t.Fatal(err)
+	}
+	if err := l.signer().SignCredential(cred); err != nil {
+		t.Fatal(err)
+	}
+	// Admission refuses expired credentials, so it is written as if it had been recorded before it expired
+	if err := l.chain.addBlockEntries([][]byte{credentialEntry(t, cred)}); err != nil {
+		t.Fatal(err)
+	}
+
+	report := l.chain.VerifyPresentation(&Presentation{CredentialID: cred.ID, Disclosures: []Disclosure{disclosures["degree"]}})
+	expectStatus(t, report, StatusExpired)
+	if err := report.Err(); !errors.Is(err, ErrCredentialExpired) {
+		t.Fatalf("Err returned %v, want %v", err, ErrCredentialExpired)
+	}
+}
@@ -0,0 +1,120 @@
+package model
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// DocumentDigest binds a credential to the document file (PDF, JPG, ...) it was issued with.
+type DocumentDigest struct {
+	SHA256    []byte `json:"sha256"`
+	Size      int64  `json:"size"`
+	MediaType string `json:"media_type"`
+}
+
+// NewDocumentDigest reads the document and returns its SHA-256 digest, size and media type.
+// If mediaType is empty it is detected from the document's contents.
+func NewDocumentDigest(r io.Reader, mediaType string) (*DocumentDigest, error) {
+	hash := sha256.New()
+	var head bytes.Buffer
+
+	// Keep the first 512 bytes, which is all content type detection looks at
+	size, err := io.Copy(io.MultiWriter(hash, &limitedWriter{w: &head, n: 512}), r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read document: %w", err)
+	}
+	if size == 0 {
+		return nil, fmt.Errorf("document is empty")
+	}
+	if mediaType == "" {
+		mediaType = http.DetectContentType(head.Bytes())
+	}
+
+	return &DocumentDigest{
+		SHA256:    hash.Sum(nil),
+		Size:      size,
+		MediaType: mediaType,
+	}, nil
+}
+
+// String formats the digest as it is covered by the credential hash.
+func (d *DocumentDigest) String() string {
+	return fmt.Sprintf("%s:%d:%s", hex.EncodeToString(d.SHA256), d.Size, d.MediaType)
+}
+
+// Matches checks that the presented document has the same digest and size as d.
+func (d *DocumentDigest) Matches(presented *DocumentDigest) error {
+	if presented.Size != d.Size {
+		return fmt.Errorf("document is %d bytes, the credential was issued for %d bytes", presented.Size, d.Size)
+	}
+	if !bytes.Equal(presented.SHA256, d.SHA256) {
+		return fmt.Errorf("document SHA-256 %x does not match the credential's %x", presented.SHA256, d.SHA256)
+	}
+	return nil
+}
+
+// AttachDocument binds the credential to the given document. It must be called before the credential is
+// signed, since the digest is covered by the credential hash.
+func (cred *Credential) AttachDocument(r io.Reader, mediaType string) error {
+	digest, err := NewDocumentDigest(r, mediaType)
+	if err != nil {
+		return err
+	}
+	cred.Document = digest
+	return nil
+}
+
+// VerifyDocument verifies the credential with the given ID and checks that the presented file
+// is the document the credential was issued with.
+func (chain *CredentialChain) VerifyDocument(id string, r io.Reader) *VerificationReport {
+	report := chain.VerifyCredentialAt(id, time.Now())
+	if report.Status == StatusNotFound {
+		return report
+	}
+
+	if err := checkDocument(report.Credential, r); err != nil {
+		report.add(CheckDocument, err)
+		report.Status = StatusInvalid
+		report.Valid = false
+		return report
+	}
+	report.add(CheckDocument, nil)
+	return report
+}
+
+func checkDocument(cred *Credential, r io.Reader) error {
+	if cred.Document == nil {
+		return fmt.Errorf("credential %s has no attached document", cred.ID)
+	}
+	presented, err := NewDocumentDigest(r, cred.Document.MediaType)
+	if err != nil {
+		return err
+	}
+	return cred.Document.Matches(presented)
+}
+
+// limitedWriter writes at most n bytes to w and silently discards the rest.
+type limitedWriter struct {
+	w io.Writer
+	n int
+}
+
+func (lw *limitedWriter) Write(p []byte) (int, error) {
+	if lw.n > 0 {
+		keep := p
+		if len(keep) > lw.n {
+			keep = keep[:lw.n]
+		}
+		written, err := lw.w.Write(keep)
+		lw.n -= written
+		if err != nil {
+			return written, err
+		}
+	}
+	return len(p), nil
+}
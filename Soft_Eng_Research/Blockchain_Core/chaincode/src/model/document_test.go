@@ -0,0 +1,81 @@
+package model
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+// testDocument is a PDF large enough to be read in several chunks.
+var testDocument = append([]byte("%PDF-1.7\n"), bytes.Repeat([]byte("transcript "), 1000)...)
+
+func TestNewDocumentDigest(t *testing.T) {
+	digest, err := NewDocumentDigest(bytes.NewReader(testDocument), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if digest.Size != int64(len(testDocument)) || digest.MediaType != "application/pdf" {
+		t.Fatalf("digest is %s", digest)
+	}
+	if digest, err := NewDocumentDigest(bytes.NewReader(testDocument), "image/png"); err != nil || digest.MediaType != "image/png" {
+		t.Fatalf("given media type was not kept: %v, %v", digest, err)
+	}
+	if _, err := NewDocumentDigest(strings.NewReader(""), ""); err == nil {
+		t.Fatal("an empty document was accepted")
+	}
+}
+
+func TestVerifyDocument(t *testing.T) {
+	l := newTestLedger(t)
+	cred := l.add(t, testStudent(1), func(cred *Credential) {
+		if err := cred.AttachDocument(bytes.NewReader(testDocument), ""); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	report := l.chain.VerifyDocument(cred.ID, bytes.NewReader(testDocument))
+	expectStatus(t, report, StatusValid)
+	if !reportChecks(report)[CheckDocument] {
+		t.Fatal("document check did not pass")
+	}
+
+	changed := bytes.Clone(testDocument)
+	changed[len(changed)-1] = '!'
+	tests := map[string][]byte{
+		"changed document":   changed,
+		"truncated document": testDocument[:len(testDocument)-1],
+		"empty document":     nil,
+	}
+	for name, presented := range tests {
+		t.Run(name, func(t *testing.T) {
+			report := l.chain.VerifyDocument(cred.ID, bytes.NewReader(presented))
+			expectStatus(t, report, StatusInvalid)
+			if failed := report.Failed(); len(failed) != 1 || failed[0].Check != CheckDocument {
+				t.Fatalf("failed checks are %+v, want only %s", failed, CheckDocument)
+			}
+		})
+	}
+}
+
+func TestVerifyDocumentWithoutDocument(t *testing.T) {
+	l := newTestLedger(t)
+	cred := l.add(t, testStudent(1), nil)
+	expectStatus(t, l.chain.VerifyDocument(cred.ID, bytes.NewReader(testDocument)), StatusInvalid)
+	expectStatus(t, l.chain.VerifyDocument("missing", bytes.NewReader(testDocument)), StatusNotFound)
+}
+
+func TestDocumentDigestIsSigned(t *testing.T) {
+	l := newTestLedger(t)
+	cred := l.issue(t, testStudent(1), func(cred *Credential) {
+		if err := cred.AttachDocument(bytes.NewReader(testDocument), ""); err != nil {
+			t.Fatal(err)
+		}
+	})
+	// Swapping the bound document changes the credential hash
+	if err := cred.AttachDocument(strings.NewReader("another file"), ""); err != nil {
+		t.Fatal(err)
+	}
+	if err := l.chain.AddCredentialModel(cred); err == nil {
+		t.Fatal("a credential whose document was swapped after signing was added")
+	}
+}
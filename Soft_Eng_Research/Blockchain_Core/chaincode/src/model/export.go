@@ -0,0 +1,390 @@
+package model
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/ed25519"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+// ExportFormat is a portable file format for a chain's blocks.
+type ExportFormat string
+
+const (
+	// FormatJSONLines writes one JSON block per line, in chain order.
+	FormatJSONLines ExportFormat = "jsonl"
+	// FormatBinary writes a magic header followed by each block's fields as length-prefixed bytes.
+	FormatBinary ExportFormat = "binary"
+)
+
+// binaryExportMagic starts every binary export, followed by the format version.
+var binaryExportMagic = []byte("CCLEDGER")
+
+// binaryExportVersion is the version of new binary exports. Version 1 exports, written before blocks had
+// a version and typed time, can still be imported.
+const binaryExportVersion = 2
+
+// ParseExportFormat returns the export format with the given name.
+func ParseExportFormat(name string) (ExportFormat, error) {
+	switch ExportFormat(name) {
+	case FormatJSONLines, FormatBinary:
+		return ExportFormat(name), nil
+	}
+	return "", fmt.Errorf("unknown export format %q", name)
+}
+
+// ImportReport describes the result of importing an exported chain.
+// When the file is not a valid chain, Block is the first block that diverges from a valid chain and
+// Position locates it in the file (a line number for JSON Lines, a byte offset for the binary format).
+type ImportReport struct {
+	Format     ExportFormat      `json:"format"`
+	Blocks     int               `json:"blocks"`
+	Valid      bool              `json:"valid"`
+	Block      int               `json:"block"`
+	Position   string            `json:"position,omitempty"`
+	Validation *ValidationReport `json:"validation,omitempty"`
+	Reason     string            `json:"reason,omitempty"`
+}
+
+// Err returns nil for a valid import, or an error saying where the file diverges.
+func (r *ImportReport) Err() error {
+	if r.Valid {
+		return nil
+	}
+	return fmt.Errorf("block %d at %s: %s", r.Block, r.Position, r.Reason)
+}
+
+// Export writes every block of the chain to w in the given format.
+func (chain *BlockChain) Export(w io.Writer, format ExportFormat) error {
+	if chain.snapshot != nil {
+		return fmt.Errorf("chain starts at snapshot height %d and cannot be exported without its earlier blocks", chain.snapshot.Height)
+	}
+	return ExportBlocks(w, chain.Blocks, format)
+}
+
+// ExportBlocks writes the blocks to w in the given format.
+func ExportBlocks(w io.Writer, blocks []Block, format ExportFormat) error {
+	bw := bufio.NewWriter(w)
+	switch format {
+	case FormatJSONLines:
+		encoder := json.NewEncoder(bw)
+		for i := range blocks {
+			if err := encoder.Encode(&blocks[i]); err != nil {
+				return fmt.Errorf("failed to export block %d: %w", i, err)
+			}
+		}
+	case FormatBinary:
+		bw.Write(binaryExportMagic)
+		bw.WriteByte(binaryExportVersion)
+		for i := range blocks {
+			bw.Write(encodeBinaryBlock(&blocks[i]))
+		}
+	default:
+		return fmt.Errorf("unknown export format %q", format)
+	}
+	return bw.Flush()
+}
+
+// ImportBlocks reads an exported chain from r, detecting its format, and verifies its hash chain.
+// The blocks read are returned even when the chain is broken, together with a report of where it diverges.
+func ImportBlocks(r io.Reader) ([]Block, *ImportReport, error) {
+	br := bufio.NewReader(r)
+	format := FormatJSONLines
+	if head, _ := br.Peek(len(binaryExportMagic)); bytes.Equal(head, binaryExportMagic) {
+		format = FormatBinary
+	}
+
+	var (
+		blocks    []Block
+		positions []string
+		err       error
+	)
+	if format == FormatBinary {
+		blocks, positions, err = readBinaryBlocks(br)
+	} else {
+		blocks, positions, err = readJSONLines(br)
+	}
+
+	report := &ImportReport{Format: format, Blocks: len(blocks), Block: -1}
+	var decodeErr *blockDecodeError
+	if errors.As(err, &decodeErr) {
+		// Everything before the undecodable block must still be a valid chain to blame the decode error
+		report.Validation = ValidateBlocks(blocks)
+		if !report.Validation.Valid && len(blocks) > 0 {
+			report.failValidation(positions)
+			return blocks, report, nil
+		}
+		report.fail(len(blocks), decodeErr.Position, decodeErr.Err.Error())
+		return blocks, report, nil
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	report.Validation = ValidateBlocks(blocks)
+	if !report.Validation.Valid {
+		report.failValidation(positions)
+		return blocks, report, nil
+	}
+	report.Valid = true
+	return blocks, report, nil
+}
+
+func (r *ImportReport) fail(block int, position, reason string) {
+	r.Valid = false
+	r.Block = block
+	r.Position = position
+	r.Reason = reason
+}
+
+// failValidation reports the first broken block found by validation at its position in the file.
+func (r *ImportReport) failValidation(positions []string) {
+	position := "end of file"
+	if r.Validation.BrokenBlock < len(positions) {
+		position = positions[r.Validation.BrokenBlock]
+	}
+	r.fail(r.Validation.BrokenBlock, position, fmt.Sprintf("%s: %s", r.Validation.Failure, r.Validation.Reason))
+}
+
+// FirstDivergence returns the position of the first block that differs between the two chains,
+// or -1 if they hold the same blocks. A chain that is a prefix of the other diverges where it ends.
+func FirstDivergence(a, b []Block) int {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if !bytes.Equal(a[i].Hash, b[i].Hash) {
+			return i
+		}
+	}
+	if len(a) != len(b) {
+		return min(len(a), len(b))
+	}
+	return -1
+}
+
+// blockDecodeError is returned when a block of an export cannot be decoded.
+type blockDecodeError struct {
+	Position string
+	Err      error
+}
+
+func (e *blockDecodeError) Error() string {
+	return fmt.Sprintf("%s: %v", e.Position, e.Err)
+}
+
+func readJSONLines(br *bufio.Reader) ([]Block, []string, error) {
+	var blocks []Block
+	var positions []string
+	for line := 1; ; line++ {
+		data, err := br.ReadBytes('\n')
+		if err != nil && err != io.EOF {
+			return nil, nil, err
+		}
+		if len(bytes.TrimSpace(data)) > 0 {
+			position := fmt.Sprintf("line %d", line)
+			var block Block
+			if err := json.Unmarshal(data, &block); err != nil {
+				return blocks, positions, &blockDecodeError{Position: position, Err: err}
+			}
+			blocks = append(blocks, block)
+			positions = append(positions, position)
+		}
+		if err == io.EOF {
+			return blocks, positions, nil
+		}
+	}
+}
+
+func readBinaryBlocks(br *bufio.Reader) ([]Block, []string, error) {
+	header := make([]byte, len(binaryExportMagic)+1)
+	if _, err := io.ReadFull(br, header); err != nil {
+		return nil, nil, err
+	}
+	version := header[len(binaryExportMagic)]
+	if version < 1 || version > binaryExportVersion {
+		return nil, nil, fmt.Errorf("unsupported binary export version %d", version)
+	}
+
+	offset := int64(len(header))
+	var blocks []Block
+	var positions []string
+	for {
+		if _, err := br.Peek(1); err == io.EOF {
+			return blocks, positions, nil
+		}
+		position := fmt.Sprintf("byte offset %d", offset)
+		counter := &countingReader{r: br}
+		block, err := decodeBinaryBlock(counter, version)
+		if err != nil {
+			return blocks, positions, &blockDecodeError{Position: position, Err: err}
+		}
+		offset += counter.n
+		blocks = append(blocks, *block)
+		positions = append(positions, position)
+	}
+}
+
+// encodeBinaryBlock encodes the block's version, its time in nanoseconds for versioned blocks, and its index,
+// followed by each of its fields as a uvarint length and its bytes.
+func encodeBinaryBlock(b *Block) []byte {
+	var buf []byte
+	field := func(value []byte) {
+		buf = binary.AppendUvarint(buf, uint64(len(value)))
+		buf = append(buf, value...)
+	}
+
+	buf = binary.AppendUvarint(buf, uint64(b.Version))
+	if b.Version > 0 {
+		buf = binary.BigEndian.AppendUint64(buf, uint64(b.Time.UnixNano()))
+	}
+	buf = binary.AppendUvarint(buf, uint64(b.Index))
+	field([]byte(b.Timestamp))
+	field(b.Data)
+	buf = binary.AppendUvarint(buf, uint64(len(b.Entries)))
+	for _, entry := range b.Entries {
+		field(entry)
+	}
+	field(b.MerkleRoot)
+	field(b.Hash)
+	field(b.PrevHash)
+	return buf
+}
+
+// maxBinaryField bounds the length of a single decoded field so a corrupt length cannot exhaust memory.
+const maxBinaryField = 64 << 20
+
+func decodeBinaryBlock(r io.ByteReader, exportVersion byte) (*Block, error) {
+	readUvarint := func() (uint64, error) {
+		value, err := binary.ReadUvarint(r)
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return value, err
+	}
+	field := func() ([]byte, error) {
+		length, err := readUvarint()
+		if err != nil {
+			return nil, err
+		}
+		if length > maxBinaryField {
+			return nil, fmt.Errorf("field length %d is too large", length)
+		}
+		if length == 0 {
+			return nil, nil
+		}
+		value := make([]byte, length)
+		for i := range value {
+			if value[i], err = r.ReadByte(); err != nil {
+				return nil, io.ErrUnexpectedEOF
+			}
+		}
+		return value, nil
+	}
+
+	var b Block
+	if exportVersion >= 2 {
+		version, err := readUvarint()
+		if err != nil {
+			return nil, err
+		}
+		b.Version = int(version)
+		if b.Version > 0 {
+			var nanos uint64
+			for i := 0; i < 8; i++ {
+				c, err := r.ReadByte()
+				if err != nil {
+					return nil, io.ErrUnexpectedEOF
+				}
+				nanos = nanos<<8 | uint64(c)
+			}
+			b.Time = time.Unix(0, int64(nanos)).UTC()
+		}
+	}
+	index, err := readUvarint()
+	if err != nil {
+		return nil, err
+	}
+	b.Index = int(index)
+	timestamp, err := field()
+	if err != nil {
+		return nil, err
+	}
+	b.Timestamp = string(timestamp)
+	if b.Data, err = field(); err != nil {
+		return nil, err
+	}
+	count, err := readUvarint()
+	if err != nil {
+		return nil, err
+	}
+	if count > maxBinaryField {
+		return nil, fmt.Errorf("entry count %d is too large", count)
+	}
+	for i := uint64(0); i < count; i++ {
+		entry, err := field()
+		if err != nil {
+			return nil, err
+		}
+		b.Entries = append(b.Entries, entry)
+	}
+	if b.MerkleRoot, err = field(); err != nil {
+		return nil, err
+	}
+	if b.Hash, err = field(); err != nil {
+		return nil, err
+	}
+	if b.PrevHash, err = field(); err != nil {
+		return nil, err
+	}
+	return &b, nil
+}
+
+// countingReader counts the bytes read through it.
+type countingReader struct {
+	r io.ByteReader
+	n int64
+}
+
+func (c *countingReader) ReadByte() (byte, error) {
+	b, err := c.r.ReadByte()
+	if err == nil {
+		c.n++
+	}
+	return b, err
+}
+
+// LoadCredentialChain opens an in-memory credential chain from the blocks of an export, trusting the given
+// super-admin keys for the issuer registry recorded on it. The chain is not backed by a store.
+func LoadCredentialChain(blocks []Block, superAdminKeys map[string]ed25519.PublicKey) (*CredentialChain, error) {
+	if len(blocks) == 0 {
+		return nil, fmt.Errorf("no blocks to load")
+	}
+	if err := ValidateBlocks(blocks).Err(); err != nil {
+		return nil, fmt.Errorf("refusing to load a broken chain: %w", err)
+	}
+	chain := &CredentialChain{
+		BlockChain:     BlockChain{Blocks: blocks},
+		SuperAdminKeys: superAdminKeys,
+	}
+	chain.RebuildIndex()
+	return chain, nil
+}
+
+// ImportChain writes the blocks of a verified import to an empty ledger store and reopens the chain from it.
+func ImportChain(blocks []Block, store *LedgerStore) (*BlockChain, error) {
+	if store.Len() != 0 {
+		return nil, fmt.Errorf("ledger store already holds %d blocks", store.Len())
+	}
+	if err := ValidateBlocks(blocks).Err(); err != nil {
+		return nil, fmt.Errorf("refusing to import a broken chain: %w", err)
+	}
+	for i := range blocks {
+		if err := store.Append(&blocks[i]); err != nil {
+			return nil, fmt.Errorf("failed to write block %d: %w", i, err)
+		}
+	}
+	return NewBlockChain(store)
+}
@@ -0,0 +1,159 @@
+package model
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+	"time"
+)
+
+// exportTestBlocks returns a valid chain holding a data block and a block with an odd number of entries.
+func exportTestBlocks(t *testing.T) []Block {
+	t.Helper()
+	chain, err := NewBlockChain(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := chain.AddBlock([]byte("opaque block data")); err != nil {
+		t.Fatal(err)
+	}
+	if err := chain.addBlockEntries(merkleEntries(3)); err != nil {
+		t.Fatal(err)
+	}
+	return chain.Blocks
+}
+
+// legacyBlock returns a version 0 block with a text timestamp, as written before blocks were versioned.
+func legacyBlock() *Block {
+	block := &Block{
+		Index:     7,
+		Timestamp: time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC).Format(time.RFC3339),
+		Data:      []byte("legacy data"),
+		PrevHash:  []byte("previous hash"),
+	}
+	block.DeriveHash()
+	return block
+}
+
+// sameBlock reports whether got has the hash, fields and entries of want.
+func sameBlock(got, want *Block) bool {
+	if got.Version != want.Version || got.Index != want.Index || got.Timestamp != want.Timestamp || !got.Time.Equal(want.Time) {
+		return false
+	}
+	if !bytes.Equal(got.Hash, want.Hash) || !bytes.Equal(got.CalculateHash(), want.Hash) {
+		return false
+	}
+	if len(got.Entries) != len(want.Entries) {
+		return false
+	}
+	for i := range got.Entries {
+		if !bytes.Equal(got.Entries[i], want.Entries[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func TestBinaryBlockRoundTrip(t *testing.T) {
+	blocks := exportTestBlocks(t)
+	tests := map[string]*Block{
+		"genesis": &blocks[0],
+		"data":    &blocks[1],
+		"entries": &blocks[2],
+		"legacy":  legacyBlock(),
+	}
+	for name, block := range tests {
+		t.Run(name, func(t *testing.T) {
+			data := encodeBinaryBlock(block)
+			decoded, err := decodeBinaryBlock(bufio.NewReader(bytes.NewReader(data)), binaryExportVersion)
+			if err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			if !sameBlock(decoded, block) {
+				t.Fatalf("decoded block %+v, want %+v", decoded, block)
+			}
+		})
+	}
+}
+
+func TestDecodeBinaryBlockTruncated(t *testing.T) {
+	blocks := exportTestBlocks(t)
+	data := encodeBinaryBlock(&blocks[2])
+	for n := 0; n < len(data); n++ {
+		_, err := decodeBinaryBlock(bufio.NewReader(bytes.NewReader(data[:n])), binaryExportVersion)
+		if !errors.Is(err, io.ErrUnexpectedEOF) {
+			t.Fatalf("decoding the first %d of %d bytes returned %v, want %v", n, len(data), err, io.ErrUnexpectedEOF)
+		}
+	}
+}
+
+func TestExportImportRoundTrip(t *testing.T) {
+	blocks := exportTestBlocks(t)
+	for _, format := range []ExportFormat{FormatJSONLines, FormatBinary} {
+		t.Run(string(format), func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := ExportBlocks(&buf, blocks, format); err != nil {
+				t.Fatal(err)
+			}
+			imported, report, err := ImportBlocks(&buf)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := report.Err(); err != nil {
+				t.Fatalf("import: %v", err)
+			}
+			if report.Format != format {
+				t.Errorf("import detected format %q, want %q", report.Format, format)
+			}
+			if len(imported) != len(blocks) {
+				t.Fatalf("imported %d blocks, want %d", len(imported), len(blocks))
+			}
+			for i := range blocks {
+				if !sameBlock(&imported[i], &blocks[i]) {
+					t.Errorf("block %d changed in the round trip", i)
+				}
+			}
+		})
+	}
+}
+
+func TestImportTruncatedBinaryExport(t *testing.T) {
+	blocks := exportTestBlocks(t)
+	var buf bytes.Buffer
+	if err := ExportBlocks(&buf, blocks, FormatBinary); err != nil {
+		t.Fatal(err)
+	}
+	data := buf.Bytes()
+	lastBlock := len(data) - len(encodeBinaryBlock(&blocks[len(blocks)-1]))
+
+	// A file cut anywhere inside the last block still yields the blocks before it and blames the last one
+	for n := lastBlock + 1; n < len(data); n++ {
+		imported, report, err := ImportBlocks(bytes.NewReader(data[:n]))
+		if err != nil {
+			t.Fatalf("importing the first %d bytes: %v", n, err)
+		}
+		if report.Valid {
+			t.Fatalf("export cut to %d of %d bytes imported as valid", n, len(data))
+		}
+		if len(imported) != len(blocks)-1 || report.Block != len(blocks)-1 {
+			t.Fatalf("export cut to %d bytes returned %d blocks and blamed block %d, want %d blocks and block %d",
+				n, len(imported), report.Block, len(blocks)-1, len(blocks)-1)
+		}
+	}
+
+	// Without its version byte the file has no readable header
+	if _, _, err := ImportBlocks(bytes.NewReader(data[:len(binaryExportMagic)])); err == nil {
+		t.Fatal("importing an export without its version byte succeeded")
+	}
+}
+
+func TestImportBinaryExportVersion(t *testing.T) {
+	var buf bytes.Buffer
+	buf.Write(binaryExportMagic)
+	buf.WriteByte(binaryExportVersion + 1)
+	if _, _, err := ImportBlocks(&buf); err == nil {
+		t.Fatal("importing an export of an unknown version succeeded")
+	}
+}
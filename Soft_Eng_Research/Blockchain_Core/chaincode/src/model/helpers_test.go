@@ -0,0 +1,108 @@
+package model
+
+import (
+	"crypto/ed25519"
+	"testing"
+	"time"
+)
+
+// testIssuerName is the name of the issuer registered on every test ledger.
+const testIssuerName = "Test University"
+
+// testLedger is an in-memory credential chain with a registered super-admin and one trusted issuer,
+// testIssuerName, for which each of signers can sign.
+type testLedger struct {
+	chain      *CredentialChain
+	superAdmin *Admin
+	signers    []*Admin
+}
+
+func newTestAdmin(t *testing.T, id, role string) *Admin {
+	t.Helper()
+	admin := &Admin{AdminID: id, Name: "Admin " + id, Role: role}
+	if err := admin.GenerateKeys(); err != nil {
+		t.Fatal(err)
+	}
+	return admin
+}
+
+// newTestLedger creates a test ledger whose issuer has three signers.
+func newTestLedger(t *testing.T) *testLedger {
+	t.Helper()
+	chain, err := NewCredentialChain(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l := &testLedger{chain: chain, superAdmin: newTestAdmin(t, "root", RoleSuperAdmin)}
+	if err := chain.RegisterSuperAdmin(l.superAdmin.AdminID, l.superAdmin.PublicKey); err != nil {
+		t.Fatal(err)
+	}
+
+	keys := make(map[string]ed25519.PublicKey)
+	for _, id := range []string{"registrar", "dean", "provost"} {
+		signer := newTestAdmin(t, id, "")
+		l.signers = append(l.signers, signer)
+		keys[id] = signer.PublicKey
+	}
+	issuer := &TrustedIssuer{
+		ID:             "test-university",
+		Name:           testIssuerName,
+		PublicKeys:     keys,
+		AccreditedFrom: time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+	if err := chain.AddTrustedIssuer(l.superAdmin, issuer); err != nil {
+		t.Fatal(err)
+	}
+	return l
+}
+
+// signer returns the issuer's first signer.
+func (l *testLedger) signer() *Admin {
+	return l.signers[0]
+}
+
+// issue returns a credential for the student signed by the issuer's first signer, without adding it to the chain.
+// edit, if given, changes the credential before it is signed.
+func (l *testLedger) issue(t *testing.T, s *Student, edit func(cred *Credential)) *Credential {
+	t.Helper()
+	cred := &Credential{
+		Type:       Certificate,
+		Issuer:     testIssuerName,
+		DateIssued: time.Now().Add(-time.Hour).UTC(),
+	}
+	if edit != nil {
+		edit(cred)
+	}
+	if err := l.signer().prepareCredential(s, cred); err != nil {
+		t.Fatal(err)
+	}
+	return cred
+}
+
+// add issues a credential to the student and adds it to the chain.
+func (l *testLedger) add(t *testing.T, s *Student, edit func(cred *Credential)) *Credential {
+	t.Helper()
+	cred := l.issue(t, s, edit)
+	if err := l.chain.AddCredentialModel(cred); err != nil {
+		t.Fatalf("add credential: %v", err)
+	}
+	return cred
+}
+
+func testStudent(id int) *Student {
+	return &Student{
+		ID:        id,
+		FirstName: "Ada",
+		LastName:  "Lovelace",
+		BirthDate: time.Date(2001, 12, 10, 0, 0, 0, 0, time.UTC),
+		StudentID: 202400000 + id,
+	}
+}
+
+// expectStatus fails the test unless the report has the given status.
+func expectStatus(t *testing.T, report *VerificationReport, want VerificationStatus) {
+	t.Helper()
+	if report.Status != want {
+		t.Fatalf("credential %s is %s, want %s; failed checks: %+v", report.CredentialID, report.Status, want, report.Failed())
+	}
+}
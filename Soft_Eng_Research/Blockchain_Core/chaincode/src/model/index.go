@@ -0,0 +1,244 @@
+package model
+
+import (
+	"sort"
+	"time"
+)
+
+// issueDateLayout is the key format of the issue date index.
+const issueDateLayout = "2006-01-02"
+
+// indexedCredential is a decoded credential together with where it is stored on the chain.
+type indexedCredential struct {
+	Credential *Credential
+	BlockIndex int
+	Entry      int
+	RecordedAt time.Time
+}
+
+// indexedPolicy is an approval policy together with where it was recorded on the chain.
+type indexedPolicy struct {
+	Policy     *ApprovalPolicy
+	BlockIndex int
+	Entry      int
+}
+
+// timedCredential is an entry of the issue date and block time range indexes.
+type timedCredential struct {
+	At time.Time
+	ID string
+}
+
+// ledgerIndex holds the secondary indexes of a chain. It is updated as blocks are appended
+// and can always be rebuilt from the blocks themselves.
+type ledgerIndex struct {
+	height   int
+	byID     map[string]*indexedCredential
+	byOwner  map[int][]string
+	byIssuer map[string][]string
+	byType   map[CredentialType][]string
+	byDate   map[string][]string
+	// issued and recorded list every credential ordered by issue date and by block time, for range queries
+	issued   []timedCredential
+	recorded []timedCredential
+	// revocations lists every revocation recorded for a credential in ledger order, including any whose signature does not verify
+	revocations map[string][]*Revocation
+	// supersededBy maps a credential ID to the ID of the version that replaced it
+	supersededBy map[string]string
+	// students holds the internal IDs already registered, and registrations the first registration of each in ledger order
+	students      map[int]bool
+	registrations []*StudentRegistration
+	// issuerEvents are replayed, after checking their signatures, to build the issuer registry
+	issuerEvents []*IssuerEvent
+	// approvalPolicies lists every approval policy in ledger order, including any whose signature does not verify
+	approvalPolicies []*indexedPolicy
+}
+
+func newLedgerIndex() *ledgerIndex {
+	return &ledgerIndex{
+		byID:         make(map[string]*indexedCredential),
+		byOwner:      make(map[int][]string),
+		byIssuer:     make(map[string][]string),
+		byType:       make(map[CredentialType][]string),
+		byDate:       make(map[string][]string),
+		revocations:  make(map[string][]*Revocation),
+		supersededBy: make(map[string]string),
+		students:     make(map[int]bool),
+	}
+}
+
+// addBlock indexes every credential, revocation, issuer event, student registration and approval policy in the block.
+func (idx *ledgerIndex) addBlock(block *Block) {
+	recordedAt, _ := block.CreatedAt()
+	for j, payload := range block.Payloads() {
+		_, value, err := DecodePayload(payload)
+		if err != nil {
+			continue
+		}
+		switch event := value.(type) {
+		case *Credential:
+			idx.addCredential(&indexedCredential{Credential: event, BlockIndex: block.Index, Entry: j, RecordedAt: recordedAt})
+		case *Revocation:
+			idx.addRevocation(event)
+		case *IssuerEvent:
+			idx.issuerEvents = append(idx.issuerEvents, event)
+		case *StudentRegistration:
+			idx.addStudent(event)
+		case *ApprovalPolicy:
+			idx.approvalPolicies = append(idx.approvalPolicies, &indexedPolicy{Policy: event, BlockIndex: block.Index, Entry: j})
+		}
+	}
+	idx.height++
+}
+
+// addSnapshot indexes the state recorded in a snapshot, as if the blocks it covers had been added.
+// The height is left at zero, since it counts the blocks the index has read from the chain itself.
+func (idx *ledgerIndex) addSnapshot(snapshot *Snapshot) {
+	for _, entry := range snapshot.Credentials {
+		cred := *entry.Credential
+		idx.addCredential(&indexedCredential{Credential: &cred, BlockIndex: entry.BlockIndex, Entry: entry.Entry, RecordedAt: entry.RecordedAt})
+	}
+	for _, revocation := range snapshot.Revocations {
+		idx.addRevocation(revocation)
+	}
+	for i := range snapshot.Students {
+		idx.addStudent(&snapshot.Students[i])
+	}
+	idx.issuerEvents = append(idx.issuerEvents, snapshot.IssuerEvents...)
+	for _, entry := range snapshot.ApprovalPolicies {
+		idx.approvalPolicies = append(idx.approvalPolicies, &indexedPolicy{Policy: entry.Policy, BlockIndex: entry.BlockIndex, Entry: entry.Entry})
+	}
+}
+
+func (idx *ledgerIndex) addStudent(registration *StudentRegistration) {
+	if idx.students[registration.ID] {
+		return
+	}
+	idx.students[registration.ID] = true
+	idx.registrations = append(idx.registrations, registration)
+}
+
+func (idx *ledgerIndex) addRevocation(revocation *Revocation) {
+	idx.revocations[revocation.CredentialID] = append(idx.revocations[revocation.CredentialID], revocation)
+}
+
+func (idx *ledgerIndex) addCredential(entry *indexedCredential) {
+	cred := entry.Credential
+	// Credentials written before IDs were assigned cannot be looked up
+	if cred.ID == "" {
+		return
+	}
+	// The first credential recorded under an ID wins, matching a scan from genesis
+	if _, exists := idx.byID[cred.ID]; exists {
+		return
+	}
+	idx.byID[cred.ID] = entry
+	idx.byOwner[cred.OwnerID] = append(idx.byOwner[cred.OwnerID], cred.ID)
+	idx.byIssuer[cred.Issuer] = append(idx.byIssuer[cred.Issuer], cred.ID)
+	idx.byType[cred.Type] = append(idx.byType[cred.Type], cred.ID)
+	date := cred.DateIssued.Format(issueDateLayout)
+	idx.byDate[date] = append(idx.byDate[date], cred.ID)
+	idx.recorded = append(idx.recorded, timedCredential{At: entry.RecordedAt, ID: cred.ID})
+
+	// Keep issue order; credentials issued at the same time stay in the order they were recorded
+	at := sort.Search(len(idx.issued), func(i int) bool {
+		return idx.issued[i].At.After(cred.DateIssued)
+	})
+	idx.issued = append(idx.issued, timedCredential{})
+	copy(idx.issued[at+1:], idx.issued[at:])
+	idx.issued[at] = timedCredential{At: cred.DateIssued, ID: cred.ID}
+
+	// Only the first new version of a credential from the same owner and issuer replaces it
+	if previous, ok := idx.byID[cred.Supersedes]; ok && cred.Supersedes != cred.ID {
+		if _, superseded := idx.supersededBy[cred.Supersedes]; !superseded &&
+			previous.Credential.OwnerID == cred.OwnerID && previous.Credential.Issuer == cred.Issuer {
+			idx.supersededBy[cred.Supersedes] = cred.ID
+		}
+	}
+}
+
+// credentials returns copies of the indexed credentials with the given IDs.
+func (idx *ledgerIndex) credentials(ids []string) []*Credential {
+	creds := make([]*Credential, 0, len(ids))
+	for _, id := range ids {
+		creds = append(creds, idx.byID[id].Credential.clone())
+	}
+	return creds
+}
+
+// indexes returns the chain's secondary indexes, catching them up with any blocks
+// that were appended without going through AddBlock.
+func (chain *BlockChain) indexes() *ledgerIndex {
+	if chain.index == nil || chain.index.height > len(chain.Blocks) {
+		chain.RebuildIndex()
+	}
+	for chain.index.height < len(chain.Blocks) {
+		chain.index.addBlock(&chain.Blocks[chain.index.height])
+	}
+	return chain.index
+}
+
+// RebuildIndex discards the secondary indexes and rebuilds them from the blocks of the chain,
+// starting from the state of the snapshot the chain was bootstrapped from, if any.
+func (chain *BlockChain) RebuildIndex() {
+	chain.index = newLedgerIndex()
+	if chain.snapshot != nil {
+		chain.index.addSnapshot(chain.snapshot)
+	}
+	for i := range chain.Blocks {
+		chain.index.addBlock(&chain.Blocks[i])
+	}
+}
+
+// FindCredentialsByOwner returns every credential issued to the student with the given ID.
+func (chain *CredentialChain) FindCredentialsByOwner(ownerID int) []*Credential {
+	idx := chain.indexes()
+	return idx.credentials(idx.byOwner[ownerID])
+}
+
+// FindCredentialsByIssuer returns every credential issued by the given issuer.
+func (chain *CredentialChain) FindCredentialsByIssuer(issuer string) []*Credential {
+	idx := chain.indexes()
+	return idx.credentials(idx.byIssuer[issuer])
+}
+
+// FindCredentialsByType returns every credential of the given type.
+func (chain *CredentialChain) FindCredentialsByType(credentialType CredentialType) []*Credential {
+	idx := chain.indexes()
+	return idx.credentials(idx.byType[credentialType])
+}
+
+// FindCredentialsIssuedOn returns every credential issued on the calendar date of day.
+func (chain *CredentialChain) FindCredentialsIssuedOn(day time.Time) []*Credential {
+	idx := chain.indexes()
+	return idx.credentials(idx.byDate[day.Format(issueDateLayout)])
+}
+
+// FindCredentialsIssuedBetween returns every credential issued from the start of the range up to,
+// but not including, its end, ordered by issue date.
+func (chain *CredentialChain) FindCredentialsIssuedBetween(from, to time.Time) []*Credential {
+	idx := chain.indexes()
+	return idx.credentials(timeRange(idx.issued, from, to))
+}
+
+// FindCredentialsRecordedBetween returns every credential in blocks created from the start of the range up to,
+// but not including, its end, in chain order.
+func (chain *CredentialChain) FindCredentialsRecordedBetween(from, to time.Time) []*Credential {
+	idx := chain.indexes()
+	return idx.credentials(timeRange(idx.recorded, from, to))
+}
+
+// timeRange returns the IDs of the entries of the sorted list that fall in [from, to).
+func timeRange(entries []timedCredential, from, to time.Time) []string {
+	start := sort.Search(len(entries), func(i int) bool {
+		return !entries[i].At.Before(from)
+	})
+	var ids []string
+	for _, entry := range entries[start:] {
+		if !entry.At.Before(to) {
+			break
+		}
+		ids = append(ids, entry.ID)
+	}
+	return ids
+}
@@ -0,0 +1,357 @@
+package model
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// RoleSuperAdmin is the role of admins allowed to manage the issuer registry.
+const RoleSuperAdmin = "super-admin"
+
+// IssuerStatus is the accreditation status of a trusted issuer.
+type IssuerStatus string
+
+const (
+	IssuerActive    IssuerStatus = "active"
+	IssuerSuspended IssuerStatus = "suspended"
+)
+
+// IssuerAction is the change an issuer registry event makes.
+type IssuerAction string
+
+const (
+	IssuerActionAdd       IssuerAction = "add"
+	IssuerActionSuspend   IssuerAction = "suspend"
+	IssuerActionReinstate IssuerAction = "reinstate"
+)
+
+// TrustedIssuer is an accredited institution allowed to issue credentials.
+// PublicKeys holds the key of every admin allowed to sign on the issuer's behalf, keyed by admin ID.
+type TrustedIssuer struct {
+	ID              string                       `json:"id"`
+	Name            string                       `json:"name"`
+	PublicKeys      map[string]ed25519.PublicKey `json:"public_keys"`
+	AccreditedFrom  time.Time                    `json:"accredited_from"`
+	AccreditedUntil *time.Time                   `json:"accredited_until,omitempty"`
+	Status          IssuerStatus                 `json:"status"`
+	// Suspensions are the periods the issuer was suspended, rebuilt from the ledger
+	Suspensions []IssuerSuspension `json:"suspensions,omitempty"`
+}
+
+// IssuerSuspension is a period during which an issuer was suspended. Until is nil while the suspension lasts.
+type IssuerSuspension struct {
+	From  time.Time  `json:"from"`
+	Until *time.Time `json:"until,omitempty"`
+}
+
+// clone returns a deep copy of the issuer that shares no memory with it.
+func (ti *TrustedIssuer) clone() *TrustedIssuer {
+	copied := *ti
+	if ti.PublicKeys != nil {
+		copied.PublicKeys = make(map[string]ed25519.PublicKey, len(ti.PublicKeys))
+		for adminID, key := range ti.PublicKeys {
+			copied.PublicKeys[adminID] = bytes.Clone(key)
+		}
+	}
+	copied.AccreditedUntil = cloneTime(ti.AccreditedUntil)
+	if ti.Suspensions != nil {
+		copied.Suspensions = make([]IssuerSuspension, len(ti.Suspensions))
+		for i, suspension := range ti.Suspensions {
+			suspension.Until = cloneTime(suspension.Until)
+			copied.Suspensions[i] = suspension
+		}
+	}
+	return &copied
+}
+
+// AccreditedAt reports whether the issuer was accredited at the given time, ignoring suspension.
+func (ti *TrustedIssuer) AccreditedAt(at time.Time) bool {
+	if at.Before(ti.AccreditedFrom) {
+		return false
+	}
+	return ti.AccreditedUntil == nil || at.Before(*ti.AccreditedUntil)
+}
+
+// SuspendedAt reports whether the issuer was suspended at the given time.
+func (ti *TrustedIssuer) SuspendedAt(at time.Time) bool {
+	for _, suspension := range ti.Suspensions {
+		if !at.Before(suspension.From) && (suspension.Until == nil || at.Before(*suspension.Until)) {
+			return true
+		}
+	}
+	return false
+}
+
+// IssuerEvent is the ledger event that adds, suspends or reinstates a trusted issuer.
+// Every event is signed by the super-admin who made the change.
+type IssuerEvent struct {
+	Action    IssuerAction   `json:"action"`
+	IssuerID  string         `json:"issuer_id"`
+	Issuer    *TrustedIssuer `json:"issuer,omitempty"`
+	Reason    string         `json:"reason,omitempty"`
+	SignedBy  string         `json:"signed_by"`
+	SignedAt  time.Time      `json:"signed_at"`
+	Signature []byte         `json:"signature,omitempty"`
+}
+
+// signingHash hashes the event without its signature.
+func (e *IssuerEvent) signingHash() ([]byte, error) {
+	unsigned := *e
+	unsigned.Signature = nil
+	data, err := json.Marshal(unsigned)
+	if err != nil {
+		return nil, err
+	}
+	hash := sha256.Sum256(data)
+	return hash[:], nil
+}
+
+// issuerEventPayload maps each action to the payload type it is recorded under.
+var issuerEventPayload = map[IssuerAction]PayloadType{
+	IssuerActionAdd:       PayloadIssuerAdded,
+	IssuerActionSuspend:   PayloadIssuerSuspended,
+	IssuerActionReinstate: PayloadIssuerReinstated,
+}
+
+// RegisterSuperAdmin trusts the given key to sign changes to the issuer registry.
+func (chain *CredentialChain) RegisterSuperAdmin(adminID string, key ed25519.PublicKey) error {
+	if len(key) != ed25519.PublicKeySize {
+		return fmt.Errorf("invalid public key for super-admin %s", adminID)
+	}
+	if chain.SuperAdminKeys == nil {
+		chain.SuperAdminKeys = make(map[string]ed25519.PublicKey)
+	}
+	chain.SuperAdminKeys[adminID] = key
+	chain.issuers = nil
+	return nil
+}
+
+// AddTrustedIssuer records a new accredited issuer on the ledger.
+func (chain *CredentialChain) AddTrustedIssuer(by *Admin, issuer *TrustedIssuer) error {
+	if issuer.ID == "" || issuer.Name == "" {
+		return fmt.Errorf("issuer must have an identifier and a name")
+	}
+	for adminID, key := range issuer.PublicKeys {
+		if len(key) != ed25519.PublicKeySize {
+			return fmt.Errorf("invalid public key for admin %s", adminID)
+		}
+	}
+	if issuer.AccreditedUntil != nil && !issuer.AccreditedUntil.After(issuer.AccreditedFrom) {
+		return fmt.Errorf("accreditation must end after it starts")
+	}
+
+	// Identifiers and names must both be unique, since credentials may name their issuer by either
+	for _, existing := range chain.IssuerRegistry() {
+		if existing.ID == issuer.ID || existing.Name == issuer.Name || existing.ID == issuer.Name || existing.Name == issuer.ID {
+			return fmt.Errorf("issuer %s is already registered", issuer.ID)
+		}
+	}
+
+	added := *issuer
+	added.Status = IssuerActive
+	return chain.recordIssuerEvent(by, &IssuerEvent{Action: IssuerActionAdd, IssuerID: issuer.ID, Issuer: &added})
+}
+
+// SuspendIssuer records that an issuer is suspended. Credentials from a suspended issuer are rejected.
+func (chain *CredentialChain) SuspendIssuer(by *Admin, issuerID, reason string) error {
+	return chain.changeIssuerStatus(by, issuerID, reason, IssuerActionSuspend, IssuerActive)
+}
+
+// ReinstateIssuer records that a suspended issuer is active again.
+func (chain *CredentialChain) ReinstateIssuer(by *Admin, issuerID, reason string) error {
+	return chain.changeIssuerStatus(by, issuerID, reason, IssuerActionReinstate, IssuerSuspended)
+}
+
+func (chain *CredentialChain) changeIssuerStatus(by *Admin, issuerID, reason string, action IssuerAction, from IssuerStatus) error {
+	if reason == "" {
+		return fmt.Errorf("reason cannot be empty")
+	}
+	issuer, ok := chain.IssuerRegistry()[issuerID]
+	if !ok {
+		return fmt.Errorf("issuer %s is not registered", issuerID)
+	}
+	if issuer.Status != from {
+		return fmt.Errorf("issuer %s is %s", issuerID, issuer.Status)
+	}
+	return chain.recordIssuerEvent(by, &IssuerEvent{Action: action, IssuerID: issuerID, Reason: reason})
+}
+
+// checkSuperAdmin checks that the admin is a registered super-admin able to sign ledger events.
+func (chain *CredentialChain) checkSuperAdmin(by *Admin) error {
+	if by.Role != RoleSuperAdmin {
+		return fmt.Errorf("admin %s is not a super-admin", by.AdminID)
+	}
+	if _, ok := chain.SuperAdminKeys[by.AdminID]; !ok {
+		return fmt.Errorf("admin %s is not a registered super-admin", by.AdminID)
+	}
+	if len(by.PrivateKey) != ed25519.PrivateKeySize {
+		return fmt.Errorf("admin %s has no signing key", by.AdminID)
+	}
+	return nil
+}
+
+// recordIssuerEvent signs the event as the super-admin and appends it to the ledger.
+func (chain *CredentialChain) recordIssuerEvent(by *Admin, event *IssuerEvent) error {
+	if err := chain.checkSuperAdmin(by); err != nil {
+		return err
+	}
+
+	event.SignedBy = by.AdminID
+	event.SignedAt = time.Now().UTC()
+	hash, err := event.signingHash()
+	if err != nil {
+		return err
+	}
+	event.Signature = ed25519.Sign(by.PrivateKey, hash)
+	if err := chain.verifyIssuerEvent(event); err != nil {
+		return err
+	}
+
+	data, err := EncodePayload(issuerEventPayload[event.Action], event)
+	if err != nil {
+		return err
+	}
+	if err := chain.addBlockEntries([][]byte{data}); err != nil {
+		return err
+	}
+	chain.issuers = nil
+	return nil
+}
+
+// verifyIssuerEvent checks the event's signature against the registered super-admin keys.
+func (chain *CredentialChain) verifyIssuerEvent(event *IssuerEvent) error {
+	key, ok := chain.SuperAdminKeys[event.SignedBy]
+	if !ok {
+		return fmt.Errorf("issuer event signed by unknown super-admin %q", event.SignedBy)
+	}
+	hash, err := event.signingHash()
+	if err != nil {
+		return err
+	}
+	if !ed25519.Verify(key, hash, event.Signature) {
+		return fmt.Errorf("issuer event for %s has an invalid signature", event.IssuerID)
+	}
+	return nil
+}
+
+// IssuerRegistry returns the trusted issuers keyed by identifier, built by replaying the issuer events on the ledger.
+// Events that are not signed by a registered super-admin are ignored.
+func (chain *CredentialChain) IssuerRegistry() map[string]*TrustedIssuer {
+	events := chain.indexes().issuerEvents
+	if chain.issuers != nil && chain.issuersEvents == len(events) {
+		return chain.issuers
+	}
+
+	registry := make(map[string]*TrustedIssuer)
+	for _, event := range events {
+		if chain.verifyIssuerEvent(event) != nil {
+			continue
+		}
+		switch event.Action {
+		case IssuerActionAdd:
+			if _, exists := registry[event.IssuerID]; !exists && event.Issuer != nil {
+				// The registry is cached, so it must not share the keys of the event on the ledger
+				issuer := event.Issuer.clone()
+				issuer.ID = event.IssuerID
+				issuer.Status = IssuerActive
+				issuer.Suspensions = nil
+				registry[event.IssuerID] = issuer
+			}
+		case IssuerActionSuspend:
+			if issuer, ok := registry[event.IssuerID]; ok && issuer.Status == IssuerActive {
+				issuer.Status = IssuerSuspended
+				issuer.Suspensions = append(issuer.Suspensions, IssuerSuspension{From: event.SignedAt})
+			}
+		case IssuerActionReinstate:
+			if issuer, ok := registry[event.IssuerID]; ok && issuer.Status == IssuerSuspended {
+				issuer.Status = IssuerActive
+				until := event.SignedAt
+				issuer.Suspensions[len(issuer.Suspensions)-1].Until = &until
+			}
+		}
+	}
+
+	chain.issuers = registry
+	chain.issuersEvents = len(events)
+	return registry
+}
+
+// FindIssuer returns the trusted issuer with the given identifier or name.
+func (chain *CredentialChain) FindIssuer(ref string) (*TrustedIssuer, error) {
+	registry := chain.IssuerRegistry()
+	if issuer, ok := registry[ref]; ok {
+		return issuer, nil
+	}
+	for _, issuer := range registry {
+		if issuer.Name == ref {
+			return issuer, nil
+		}
+	}
+	return nil, fmt.Errorf("issuer %q is not a registered issuer", ref)
+}
+
+// checkIssuerTrust checks that the credential's issuer is registered and active, and is trusted to add the
+// credential at the given time, the time of the block that records it.
+func (chain *CredentialChain) checkIssuerTrust(cred *Credential, at time.Time) error {
+	issuer, err := chain.FindIssuer(cred.Issuer)
+	if err != nil {
+		return err
+	}
+	if issuer.Status != IssuerActive {
+		return fmt.Errorf("issuer %s is %s", issuer.ID, issuer.Status)
+	}
+	return chain.checkIssuerTrustedAt(cred, at)
+}
+
+// checkIssuerTrustedAt checks that the credential's issuer is registered, and was accredited and not suspended
+// both when the credential was issued and at recordedAt, when the credential was recorded on the chain.
+// The issue date is chosen by the signer, so a credential backdated into an accreditation that has since ended
+// is refused by the time it was recorded. Ending or suspending an issuer's accreditation later does not affect
+// the credentials recorded before.
+func (chain *CredentialChain) checkIssuerTrustedAt(cred *Credential, recordedAt time.Time) error {
+	issuer, err := chain.FindIssuer(cred.Issuer)
+	if err != nil {
+		return err
+	}
+	for _, at := range []time.Time{cred.DateIssued, recordedAt} {
+		if !issuer.AccreditedAt(at) {
+			return fmt.Errorf("issuer %s was not accredited on %s", issuer.ID, at.Format(time.RFC3339))
+		}
+		if issuer.SuspendedAt(at) {
+			return fmt.Errorf("issuer %s was suspended on %s", issuer.ID, at.Format(time.RFC3339))
+		}
+	}
+	return nil
+}
+
+// signerKey returns the public key the given admin uses to sign for the credential's issuer.
+func (chain *CredentialChain) signerKey(cred *Credential, adminID string) (ed25519.PublicKey, error) {
+	issuer, err := chain.FindIssuer(cred.Issuer)
+	if err != nil {
+		return nil, err
+	}
+	key, ok := issuer.PublicKeys[adminID]
+	if !ok {
+		return nil, fmt.Errorf("admin %q is not a signer for issuer %s", adminID, issuer.ID)
+	}
+	return key, nil
+}
+
+// verifyIssuerSignature checks the credential's signature against its signer's key in the issuer registry.
+func (chain *CredentialChain) verifyIssuerSignature(cred *Credential) error {
+	if len(cred.Signature) == 0 {
+		return fmt.Errorf("credential %s is not signed by its issuer", cred.ID)
+	}
+	key, err := chain.signerKey(cred, cred.SignerID)
+	if err != nil {
+		return err
+	}
+	if !VerifyCredentialSignature(cred, key) {
+		return fmt.Errorf("credential %s has an invalid issuer signature", cred.ID)
+	}
+	return nil
+}
@@ -0,0 +1,114 @@
+package model
+
+import (
+	"crypto/ed25519"
+	"strings"
+	"testing"
+	"time"
+)
+
+// addIssuer registers another issuer on the test ledger, accredited from from until until,
+// and returns an admin who signs for it.
+func (l *testLedger) addIssuer(t *testing.T, name string, from time.Time, until *time.Time) *Admin {
+	t.Helper()
+	signer := newTestAdmin(t, "signer-"+name, "")
+	issuer := &TrustedIssuer{
+		ID:              strings.ToLower(strings.ReplaceAll(name, " ", "-")),
+		Name:            name,
+		PublicKeys:      map[string]ed25519.PublicKey{signer.AdminID: signer.PublicKey},
+		AccreditedFrom:  from,
+		AccreditedUntil: until,
+	}
+	if err := l.chain.AddTrustedIssuer(l.superAdmin, issuer); err != nil {
+		t.Fatal(err)
+	}
+	return signer
+}
+
+// signedCredential returns a credential of the issuer dated issued, signed by signer without checking its dates.
+func signedCredential(t *testing.T, signer *Admin, issuer string, issued time.Time) *Credential {
+	t.Helper()
+	id, err := NewCredentialID()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cred := &Credential{ID: id, OwnerID: 1, Type: Certificate, Issuer: issuer, DateIssued: issued}
+	if err := signer.SignCredential(cred); err != nil {
+		t.Fatal(err)
+	}
+	return cred
+}
+
+func TestBackdatedCredentialIsRefused(t *testing.T) {
+	l := newTestLedger(t)
+	until := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	signer := l.addIssuer(t, "Closed College", time.Date(2010, 1, 1, 0, 0, 0, 0, time.UTC), &until)
+	// Dated inside the accreditation, but signed long after it ended
+	cred := signedCredential(t, signer, "Closed College", time.Date(2019, 6, 1, 0, 0, 0, 0, time.UTC))
+
+	if err := l.chain.AddCredentialModel(cred); err == nil {
+		t.Fatal("a credential backdated into an ended accreditation was added")
+	}
+	if err := l.chain.AddBlockEntries([][]byte{credentialEntry(t, cred)}, time.Now()); err == nil {
+		t.Fatal("a block holding a backdated credential was added")
+	}
+
+	// Written past the checks, it still does not verify
+	if err := l.chain.addBlockEntries([][]byte{credentialEntry(t, cred)}); err != nil {
+		t.Fatal(err)
+	}
+	report := l.chain.VerifyCredential(cred.ID)
+	expectStatus(t, report, StatusInvalid)
+	for _, check := range report.Failed() {
+		if check.Check == CheckIssuerTrust {
+			return
+		}
+	}
+	t.Fatalf("backdated credential failed %+v, want the issuer trust check", report.Failed())
+}
+
+func TestIssuerTrustAtBlockTime(t *testing.T) {
+	l := newTestLedger(t)
+	now := time.Now().Add(time.Minute)
+	until := now.Add(2 * time.Hour)
+	signer := l.addIssuer(t, "Short College", now.Add(-24*time.Hour), &until)
+
+	recorded := signedCredential(t, signer, "Short College", now)
+	if err := l.chain.AddBlockEntries([][]byte{credentialEntry(t, recorded)}, now.Add(time.Hour)); err != nil {
+		t.Fatalf("credential recorded while its issuer was accredited was refused: %v", err)
+	}
+	late := signedCredential(t, signer, "Short College", now)
+	if err := l.chain.AddBlockEntries([][]byte{credentialEntry(t, late)}, now.Add(3*time.Hour)); err == nil {
+		t.Fatal("credential recorded after its issuer's accreditation ended was added")
+	}
+
+	// The accreditation ending later does not affect the credential recorded before
+	expectStatus(t, l.chain.VerifyCredentialAt(recorded.ID, now.Add(3*time.Hour)), StatusValid)
+}
+
+func TestIssuerSuspension(t *testing.T) {
+	l := newTestLedger(t)
+	before := l.add(t, testStudent(1), nil)
+
+	if err := l.chain.SuspendIssuer(l.superAdmin, "test-university", "under review"); err != nil {
+		t.Fatal(err)
+	}
+	if err := l.chain.AddCredentialModel(l.issue(t, testStudent(2), nil)); err == nil {
+		t.Fatal("a suspended issuer added a credential")
+	}
+	// A credential recorded during the suspension without the checks does not verify
+	during := l.issue(t, testStudent(3), nil)
+	if err := l.chain.addBlockEntries([][]byte{credentialEntry(t, during)}); err != nil {
+		t.Fatal(err)
+	}
+	expectStatus(t, l.chain.VerifyCredential(during.ID), StatusInvalid)
+	// Credentials recorded before the suspension stay valid
+	expectStatus(t, l.chain.VerifyCredential(before.ID), StatusValid)
+
+	if err := l.chain.ReinstateIssuer(l.superAdmin, "test-university", "review passed"); err != nil {
+		t.Fatal(err)
+	}
+	after := l.add(t, testStudent(4), nil)
+	expectStatus(t, l.chain.VerifyCredential(after.ID), StatusValid)
+	expectStatus(t, l.chain.VerifyCredential(during.ID), StatusInvalid)
+}
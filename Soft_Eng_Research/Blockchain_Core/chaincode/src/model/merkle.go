@@ -0,0 +1,129 @@
+package model
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+)
+
+// Leaves and inner nodes are hashed with different prefixes so an inner node can never be passed off as a leaf.
+const (
+	merkleLeafPrefix = 0x00
+	merkleNodePrefix = 0x01
+)
+
+// ProofStep is one sibling hash on the path from a leaf to the Merkle root.
+type ProofStep struct {
+	Hash []byte `json:"hash"`
+	Left bool   `json:"left"` // true when the sibling is on the left of the running hash
+}
+
+// MerkleProof proves that a leaf is part of a Merkle tree.
+type MerkleProof struct {
+	LeafIndex int         `json:"leaf_index"`
+	Steps     []ProofStep `json:"steps"`
+}
+
+// MerkleLeafHash hashes a block entry as a Merkle tree leaf.
+func MerkleLeafHash(leaf []byte) []byte {
+	hash := sha256.Sum256(append([]byte{merkleLeafPrefix}, leaf...))
+	return hash[:]
+}
+
+func merkleNodeHash(left, right []byte) []byte {
+	data := make([]byte, 0, 1+len(left)+len(right))
+	data = append(data, merkleNodePrefix)
+	data = append(data, left...)
+	data = append(data, right...)
+	hash := sha256.Sum256(data)
+	return hash[:]
+}
+
+// merkleLevels builds every level of the tree, from the leaf hashes up to the root.
+// A node without a sibling is carried up to the next level unchanged.
+func merkleLevels(leaves [][]byte) [][][]byte {
+	level := make([][]byte, len(leaves))
+	for i, leaf := range leaves {
+		level[i] = MerkleLeafHash(leaf)
+	}
+
+	levels := [][][]byte{level}
+	for len(level) > 1 {
+		next := make([][]byte, 0, (len(level)+1)/2)
+		for i := 0; i < len(level); i += 2 {
+			if i+1 == len(level) {
+				next = append(next, level[i])
+				continue
+			}
+			next = append(next, merkleNodeHash(level[i], level[i+1]))
+		}
+		levels = append(levels, next)
+		level = next
+	}
+	return levels
+}
+
+// MerkleRoot computes the Merkle root of the given entries. It returns nil when there are no entries.
+func MerkleRoot(entries [][]byte) []byte {
+	if len(entries) == 0 {
+		return nil
+	}
+	levels := merkleLevels(entries)
+	return levels[len(levels)-1][0]
+}
+
+// BuildMerkleProof builds the proof that entries[index] is included under MerkleRoot(entries).
+func BuildMerkleProof(entries [][]byte, index int) (*MerkleProof, error) {
+	if index < 0 || index >= len(entries) {
+		return nil, fmt.Errorf("leaf %d is out of range", index)
+	}
+
+	proof := &MerkleProof{LeafIndex: index}
+	levels := merkleLevels(entries)
+	pos := index
+	for _, level := range levels[:len(levels)-1] {
+		sibling := pos ^ 1
+		if sibling < len(level) {
+			proof.Steps = append(proof.Steps, ProofStep{Hash: level[sibling], Left: sibling < pos})
+		}
+		pos /= 2
+	}
+	return proof, nil
+}
+
+// VerifyMerkleProof checks that leaf hashes up to root through the steps of proof.
+func VerifyMerkleProof(leaf []byte, proof *MerkleProof, root []byte) bool {
+	if proof == nil || len(root) == 0 {
+		return false
+	}
+
+	hash := MerkleLeafHash(leaf)
+	for _, step := range proof.Steps {
+		if step.Left {
+			hash = merkleNodeHash(step.Hash, hash)
+		} else {
+			hash = merkleNodeHash(hash, step.Hash)
+		}
+	}
+	return bytes.Equal(hash, root)
+}
+
+// InclusionProof proves that a credential is recorded in a block without needing the rest of the block.
+// Header is the block without its entries, so a verifier can recompute the block hash from it.
+type InclusionProof struct {
+	CredentialID string      `json:"credential_id"`
+	Entry        []byte      `json:"entry"`
+	Header       Block       `json:"header"`
+	Proof        MerkleProof `json:"proof"`
+}
+
+// Verify checks that the header hashes to its block hash and that the entry is included under its Merkle root.
+func (p *InclusionProof) Verify() error {
+	if !bytes.Equal(p.Header.Hash, p.Header.CalculateHash()) {
+		return fmt.Errorf("block %d header does not match its hash", p.Header.Index)
+	}
+	if !VerifyMerkleProof(p.Entry, &p.Proof, p.Header.MerkleRoot) {
+		return fmt.Errorf("credential %s is not included under the Merkle root of block %d", p.CredentialID, p.Header.Index)
+	}
+	return nil
+}
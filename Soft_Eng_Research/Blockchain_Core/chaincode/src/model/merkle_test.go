@@ -0,0 +1,115 @@
+package model
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+)
+
+func merkleEntries(n int) [][]byte {
+	entries := make([][]byte, n)
+	for i := range entries {
+		entries[i] = []byte(fmt.Sprintf("entry %d", i))
+	}
+	return entries
+}
+
+func TestMerkleProofsForEveryLeafCount(t *testing.T) {
+	for n := 1; n <= 9; n++ {
+		t.Run(fmt.Sprintf("%d leaves", n), func(t *testing.T) {
+			entries := merkleEntries(n)
+			root := MerkleRoot(entries)
+			for i, entry := range entries {
+				proof, err := BuildMerkleProof(entries, i)
+				if err != nil {
+					t.Fatalf("proof for leaf %d: %v", i, err)
+				}
+				if !VerifyMerkleProof(entry, proof, root) {
+					t.Errorf("proof for leaf %d does not verify", i)
+				}
+				if VerifyMerkleProof([]byte("tampered"), proof, root) {
+					t.Errorf("proof for leaf %d verifies a different entry", i)
+				}
+				if other := entries[(i+1)%n]; n > 1 && VerifyMerkleProof(other, proof, root) {
+					t.Errorf("proof for leaf %d verifies leaf %d", i, (i+1)%n)
+				}
+			}
+		})
+	}
+}
+
+func TestMerkleRootCarriesUnpairedNode(t *testing.T) {
+	entries := merkleEntries(5)
+	leaf := make([][]byte, len(entries))
+	for i, entry := range entries {
+		leaf[i] = MerkleLeafHash(entry)
+	}
+
+	// The fifth leaf has no sibling on the first two levels, so it is paired only with the root of the other four
+	left := merkleNodeHash(merkleNodeHash(leaf[0], leaf[1]), merkleNodeHash(leaf[2], leaf[3]))
+	want := merkleNodeHash(left, leaf[4])
+	if root := MerkleRoot(entries); !bytes.Equal(root, want) {
+		t.Fatalf("root of 5 leaves is %x, want %x", root, want)
+	}
+
+	proof, err := BuildMerkleProof(entries, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(proof.Steps) != 1 || !proof.Steps[0].Left || !bytes.Equal(proof.Steps[0].Hash, left) {
+		t.Fatalf("proof for the unpaired leaf has steps %+v, want only the left subtree", proof.Steps)
+	}
+}
+
+func TestMerkleSingleLeaf(t *testing.T) {
+	entries := merkleEntries(1)
+	if root := MerkleRoot(entries); !bytes.Equal(root, MerkleLeafHash(entries[0])) {
+		t.Fatal("root of a single leaf is not its leaf hash")
+	}
+	proof, err := BuildMerkleProof(entries, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(proof.Steps) != 0 {
+		t.Fatalf("proof for a single leaf has %d steps", len(proof.Steps))
+	}
+}
+
+func TestBuildMerkleProofOutOfRange(t *testing.T) {
+	entries := merkleEntries(3)
+	for _, index := range []int{-1, 3} {
+		if _, err := BuildMerkleProof(entries, index); err == nil {
+			t.Errorf("proof for leaf %d of 3 succeeded", index)
+		}
+	}
+	if MerkleRoot(nil) != nil {
+		t.Error("root of no entries is not nil")
+	}
+}
+
+func TestInclusionProofOddEntryBlock(t *testing.T) {
+	chain, err := NewBlockChain(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	entries := merkleEntries(3)
+	if err := chain.addBlockEntries(entries); err != nil {
+		t.Fatal(err)
+	}
+	block := chain.tip()
+
+	for i := range entries {
+		proof, err := chain.ProveInclusion(block, i)
+		if err != nil {
+			t.Fatalf("prove entry %d: %v", i, err)
+		}
+		if err := proof.Verify(); err != nil {
+			t.Errorf("inclusion proof for entry %d: %v", i, err)
+		}
+
+		proof.Entry = entries[(i+1)%len(entries)]
+		if err := proof.Verify(); err == nil {
+			t.Errorf("inclusion proof for entry %d verifies entry %d", i, (i+1)%len(entries))
+		}
+	}
+}
@@ -0,0 +1,144 @@
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// PayloadType tags the kind of event a ledger entry carries.
+type PayloadType string
+
+const (
+	PayloadGenesis           PayloadType = "genesis"
+	PayloadCredentialIssued  PayloadType = "credential-issued"
+	PayloadCredentialRevoked PayloadType = "credential-revoked"
+	PayloadStudentRegistered PayloadType = "student-registered"
+	PayloadIssuerAdded       PayloadType = "issuer-added"
+	PayloadIssuerSuspended   PayloadType = "issuer-suspended"
+	PayloadIssuerReinstated  PayloadType = "issuer-reinstated"
+	PayloadApprovalPolicy    PayloadType = "approval-policy"
+)
+
+// legacyPayloadVersion is the version given to entries written before envelopes existed.
+const legacyPayloadVersion = 0
+
+// legacyGenesisData is the data of genesis blocks written before envelopes existed.
+const legacyGenesisData = "Genesis Block"
+
+// Envelope wraps every ledger entry with its payload type and the schema version of the payload.
+type Envelope struct {
+	Type    PayloadType     `json:"type"`
+	Version int             `json:"version"`
+	Payload json.RawMessage `json:"payload"`
+}
+
+// GenesisPayload is the payload of the genesis block.
+type GenesisPayload struct {
+	Message string `json:"message"`
+}
+
+// PayloadDecoder decodes the payload of an entry into its Go value.
+type PayloadDecoder func(payload []byte) (interface{}, error)
+
+type payloadKey struct {
+	Type    PayloadType
+	Version int
+}
+
+var (
+	payloadDecoders = make(map[payloadKey]PayloadDecoder)
+	payloadVersions = make(map[PayloadType]int)
+)
+
+// RegisterPayloadDecoder registers the decoder for one version of a payload type.
+// The highest registered version of a type is the one new entries are written with.
+func RegisterPayloadDecoder(payloadType PayloadType, version int, decoder PayloadDecoder) {
+	payloadDecoders[payloadKey{payloadType, version}] = decoder
+	if version > payloadVersions[payloadType] {
+		payloadVersions[payloadType] = version
+	}
+}
+
+// jsonDecoder returns a decoder that unmarshals a JSON payload into a new value of type T.
+func jsonDecoder[T any]() PayloadDecoder {
+	return func(payload []byte) (interface{}, error) {
+		value := new(T)
+		if err := json.Unmarshal(payload, value); err != nil {
+			return nil, err
+		}
+		return value, nil
+	}
+}
+
+func init() {
+	RegisterPayloadDecoder(PayloadGenesis, 1, jsonDecoder[GenesisPayload]())
+	RegisterPayloadDecoder(PayloadCredentialIssued, 1, jsonDecoder[Credential]())
+	RegisterPayloadDecoder(PayloadCredentialRevoked, 1, jsonDecoder[Revocation]())
+	RegisterPayloadDecoder(PayloadStudentRegistered, 2, jsonDecoder[StudentRegistration]())
+	RegisterPayloadDecoder(PayloadIssuerAdded, 1, jsonDecoder[IssuerEvent]())
+	RegisterPayloadDecoder(PayloadIssuerSuspended, 1, jsonDecoder[IssuerEvent]())
+	RegisterPayloadDecoder(PayloadIssuerReinstated, 1, jsonDecoder[IssuerEvent]())
+	RegisterPayloadDecoder(PayloadApprovalPolicy, 1, jsonDecoder[ApprovalPolicy]())
+
+	// Entries written before envelopes carried no type, but have the same shape as version 1
+	RegisterPayloadDecoder(PayloadGenesis, legacyPayloadVersion, func(payload []byte) (interface{}, error) {
+		return &GenesisPayload{Message: string(payload)}, nil
+	})
+	RegisterPayloadDecoder(PayloadCredentialIssued, legacyPayloadVersion, jsonDecoder[Credential]())
+	// Version 1 registrations recorded the student's details in clear instead of committing them
+	RegisterPayloadDecoder(PayloadStudentRegistered, 1, decodeStudentRegistrationV1)
+}
+
+// EncodePayload wraps the payload in an envelope of the given type at the type's current version.
+func EncodePayload(payloadType PayloadType, payload interface{}) ([]byte, error) {
+	version, ok := payloadVersions[payloadType]
+	if !ok {
+		return nil, fmt.Errorf("unknown payload type %q", payloadType)
+	}
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode %s payload: %w", payloadType, err)
+	}
+	return json.Marshal(Envelope{Type: payloadType, Version: version, Payload: data})
+}
+
+// DecodePayload decodes a ledger entry into its envelope and the Go value of its payload.
+func DecodePayload(entry []byte) (*Envelope, interface{}, error) {
+	envelope, err := openEnvelope(entry)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	decoder, ok := payloadDecoders[payloadKey{envelope.Type, envelope.Version}]
+	if !ok {
+		return envelope, nil, fmt.Errorf("no decoder for %s payload version %d", envelope.Type, envelope.Version)
+	}
+	value, err := decoder(envelope.Payload)
+	if err != nil {
+		return envelope, nil, fmt.Errorf("failed to decode %s payload version %d: %w", envelope.Type, envelope.Version, err)
+	}
+	return envelope, value, nil
+}
+
+// openEnvelope reads the envelope of an entry, recognizing entries written before envelopes existed.
+func openEnvelope(entry []byte) (*Envelope, error) {
+	if string(entry) == legacyGenesisData {
+		return &Envelope{Type: PayloadGenesis, Version: legacyPayloadVersion, Payload: entry}, nil
+	}
+
+	// Legacy credentials have a numeric "type", so the type is only read as a string when it is one
+	var probe struct {
+		Type    json.RawMessage `json:"type"`
+		Version *int            `json:"version"`
+		Payload json.RawMessage `json:"payload"`
+	}
+	if err := json.Unmarshal(entry, &probe); err != nil {
+		return nil, fmt.Errorf("entry is neither an envelope nor a legacy payload: %w", err)
+	}
+
+	var payloadType PayloadType
+	if probe.Version != nil && probe.Payload != nil && json.Unmarshal(probe.Type, &payloadType) == nil && payloadType != "" {
+		return &Envelope{Type: payloadType, Version: *probe.Version, Payload: probe.Payload}, nil
+	}
+	return &Envelope{Type: PayloadCredentialIssued, Version: legacyPayloadVersion, Payload: entry}, nil
+}
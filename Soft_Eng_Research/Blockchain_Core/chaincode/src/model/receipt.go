@@ -0,0 +1,79 @@
+package model
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"fmt"
+)
+
+// Receipt is the portable record a student keeps of a credential's issuance. It holds everything needed to check
+// the credential offline: the credential, the block it was recorded in, a Merkle proof of its entry and the issuer's
+// signature and key. Headers links the credential's block to the chain's tip when the receipt was made, whose hash
+// is the checkpoint a verifier compares the receipt against.
+type Receipt struct {
+	Credential      *Credential       `json:"credential"`
+	BlockIndex      int               `json:"block_index"`
+	BlockHash       []byte            `json:"block_hash"`
+	PrevHash        []byte            `json:"prev_hash"`
+	Inclusion       InclusionProof    `json:"inclusion"`
+	SignerID        string            `json:"signer_id"`
+	IssuerSignature []byte            `json:"issuer_signature"`
+	SignerKey       ed25519.PublicKey `json:"signer_key"`
+	Headers         []Block           `json:"headers,omitempty"`
+}
+
+// Checkpoint returns the hash of the last block of the chain, which receipts made now are tied to.
+func (chain *BlockChain) Checkpoint() []byte {
+	return bytes.Clone(chain.tip().Hash)
+}
+
+// AddCredentialWithReceipt adds a signed credential to the blockchain and returns its receipt.
+func (chain *CredentialChain) AddCredentialWithReceipt(cred *Credential) (*Receipt, error) {
+	if err := chain.AddCredentialModel(cred); err != nil {
+		return nil, err
+	}
+	return chain.Receipt(cred.ID)
+}
+
+// Receipt builds the receipt of the credential with the given ID, tied to the current checkpoint of the chain.
+// A receipt can be rebuilt at any time to tie it to a later checkpoint.
+func (chain *CredentialChain) Receipt(id string) (*Receipt, error) {
+	cred, block, _, err := chain.locateCredential(id)
+	if err != nil {
+		return nil, err
+	}
+	if len(cred.Signature) == 0 {
+		return nil, fmt.Errorf("credential %s is not signed by its issuer", id)
+	}
+	key, err := chain.signerKey(cred, cred.SignerID)
+	if err != nil {
+		return nil, err
+	}
+	proof, err := chain.ProveCredential(id)
+	if err != nil {
+		return nil, err
+	}
+
+	receipt := &Receipt{
+		Credential:      cred,
+		BlockIndex:      block.Index,
+		BlockHash:       bytes.Clone(block.Hash),
+		PrevHash:        bytes.Clone(block.PrevHash),
+		Inclusion:       *proof,
+		SignerID:        cred.SignerID,
+		IssuerSignature: cred.Signature,
+		SignerKey:       bytes.Clone(key),
+	}
+	for index := block.Index + 1; index < chain.Height(); index++ {
+		receipt.Headers = append(receipt.Headers, chain.block(index).Header())
+	}
+	return receipt, nil
+}
+
+// Checkpoint returns the hash of the last block the receipt is linked to.
+func (r *Receipt) Checkpoint() []byte {
+	if len(r.Headers) > 0 {
+		return r.Headers[len(r.Headers)-1].Hash
+	}
+	return r.BlockHash
+}
@@ -0,0 +1,64 @@
+package model
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestAddCredentialWithReceipt(t *testing.T) {
+	l := newTestLedger(t)
+	cred := l.issue(t, testStudent(1), nil)
+	receipt, err := l.chain.AddCredentialWithReceipt(cred)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	block := l.chain.block(receipt.BlockIndex)
+	if !bytes.Equal(receipt.BlockHash, block.Hash) || !bytes.Equal(receipt.PrevHash, block.PrevHash) {
+		t.Fatal("receipt does not name the credential's block")
+	}
+	if receipt.SignerID != l.signer().AdminID || !bytes.Equal(receipt.SignerKey, l.signer().PublicKey) {
+		t.Fatalf("receipt names signer %s", receipt.SignerID)
+	}
+	if !bytes.Equal(receipt.IssuerSignature, cred.Signature) || receipt.Credential.ID != cred.ID {
+		t.Fatal("receipt does not hold the issued credential")
+	}
+	if err := receipt.Inclusion.Verify(); err != nil {
+		t.Fatal(err)
+	}
+	if len(receipt.Headers) != 0 || !bytes.Equal(receipt.Checkpoint(), l.chain.Checkpoint()) {
+		t.Fatal("receipt of the last block is not tied to the chain's checkpoint")
+	}
+}
+
+func TestReceiptLinksToLaterCheckpoint(t *testing.T) {
+	l := newTestLedger(t)
+	cred := l.add(t, testStudent(1), nil)
+	first, err := l.chain.Receipt(cred.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.add(t, testStudent(2), nil)
+	l.add(t, testStudent(3), nil)
+
+	receipt, err := l.chain.Receipt(cred.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(receipt.Headers) != 2 || !bytes.Equal(receipt.Checkpoint(), l.chain.Checkpoint()) {
+		t.Fatalf("receipt holds %d headers and does not reach the chain's checkpoint", len(receipt.Headers))
+	}
+	prev := first.BlockHash
+	for _, header := range receipt.Headers {
+		if !bytes.Equal(header.PrevHash, prev) || !bytes.Equal(header.Hash, header.CalculateHash()) {
+			t.Fatalf("header of block %d does not link to the block before it", header.Index)
+		}
+		if len(header.Entries) != 0 || len(header.Data) != 0 {
+			t.Fatalf("header of block %d carries the block's entries", header.Index)
+		}
+		prev = header.Hash
+	}
+	if _, err := l.chain.Receipt("missing"); err == nil {
+		t.Fatal("a receipt was built for a missing credential")
+	}
+}
@@ -0,0 +1,131 @@
+package model
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"fmt"
+	"time"
+)
+
+// Revocation is the ledger event recording that a credential was revoked.
+type Revocation struct {
+	CredentialID string    `json:"credential_id"`
+	Reason       string    `json:"reason"`
+	RevokedBy    string    `json:"revoked_by"`
+	RevokedAt    time.Time `json:"revoked_at"`
+	Signature    []byte    `json:"signature,omitempty"`
+}
+
+// CredentialRevokedError is returned when verifying a credential that has been revoked.
+type CredentialRevokedError struct {
+	Revocation *Revocation
+}
+
+func (e *CredentialRevokedError) Error() string {
+	r := e.Revocation
+	return fmt.Sprintf("credential %s was revoked by %s on %s: %s", r.CredentialID, r.RevokedBy, r.RevokedAt.Format(time.RFC3339), r.Reason)
+}
+
+// revocationFormatVersion is the version byte that starts a serialized revocation.
+const revocationFormatVersion = 1
+
+// Serialize converts the revocation to the byte format signed by the revoking admin. Like a credential's
+// SerializeCanonical, it starts with a version byte followed by every field as a length-prefixed value,
+// so two different revocations never serialize alike.
+func (r *Revocation) Serialize() []byte {
+	var buf bytes.Buffer
+	buf.WriteByte(revocationFormatVersion)
+	writeCanonicalField(&buf, r.CredentialID)
+	writeCanonicalField(&buf, r.Reason)
+	writeCanonicalField(&buf, r.RevokedBy)
+	writeCanonicalField(&buf, r.RevokedAt.UTC().Format(time.RFC3339Nano))
+	return buf.Bytes()
+}
+
+func (r *Revocation) signingHash() []byte {
+	hash := sha256.Sum256(r.Serialize())
+	return hash[:]
+}
+
+// RevokeCredential records the revocation of a credential on the ledger.
+// The revocation is signed by the revoking admin, who must be a super-admin or a signer for the credential's issuer.
+func (chain *CredentialChain) RevokeCredential(id, reason string, admin *Admin) error {
+	if reason == "" {
+		return fmt.Errorf("revocation reason cannot be empty")
+	}
+	cred, err := chain.FindCredentialByID(id)
+	if err != nil {
+		return err
+	}
+	if existing, _ := chain.FindRevocation(id); existing != nil {
+		return fmt.Errorf("credential %s is already revoked", id)
+	}
+
+	if len(admin.PrivateKey) != ed25519.PrivateKeySize {
+		return fmt.Errorf("admin %s has no signing key", admin.AdminID)
+	}
+	key, err := chain.revokerKey(cred, admin.AdminID)
+	if err != nil {
+		return err
+	}
+
+	revocation := &Revocation{
+		CredentialID: id,
+		Reason:       reason,
+		RevokedBy:    admin.AdminID,
+		RevokedAt:    time.Now().UTC(),
+	}
+	revocation.Signature = ed25519.Sign(admin.PrivateKey, revocation.signingHash())
+	if !ed25519.Verify(key, revocation.signingHash(), revocation.Signature) {
+		return fmt.Errorf("admin %s signing key does not match its registered key", admin.AdminID)
+	}
+
+	revData, err := EncodePayload(PayloadCredentialRevoked, revocation)
+	if err != nil {
+		return err
+	}
+	return chain.addBlockEntries([][]byte{revData})
+}
+
+// revokerKey returns the registered key of an admin allowed to revoke the credential.
+func (chain *CredentialChain) revokerKey(cred *Credential, adminID string) (ed25519.PublicKey, error) {
+	if key, ok := chain.SuperAdminKeys[adminID]; ok {
+		return key, nil
+	}
+	return chain.signerKey(cred, adminID)
+}
+
+// FindRevocation returns the first revocation recorded for the credential with the given ID whose signature
+// verifies. Revocations not signed by an admin allowed to revoke the credential are ignored.
+func (chain *CredentialChain) FindRevocation(id string) (*Revocation, error) {
+	for _, revocation := range chain.indexes().revocations[id] {
+		if chain.verifyRevocation(revocation) == nil {
+			return revocation.clone(), nil
+		}
+	}
+	return nil, fmt.Errorf("no revocation found for credential %s", id)
+}
+
+// clone returns a deep copy of the revocation that shares no memory with it.
+func (r *Revocation) clone() *Revocation {
+	copied := *r
+	copied.Signature = bytes.Clone(r.Signature)
+	return &copied
+}
+
+// verifyRevocation checks that the revocation is signed by an admin allowed to revoke the credential.
+func (chain *CredentialChain) verifyRevocation(revocation *Revocation) error {
+	cred, err := chain.FindCredentialByID(revocation.CredentialID)
+	if err != nil {
+		return err
+	}
+	key, err := chain.revokerKey(cred, revocation.RevokedBy)
+	if err != nil {
+		return err
+	}
+	if !ed25519.Verify(key, revocation.signingHash(), revocation.Signature) {
+		return fmt.Errorf("revocation of credential %s has an invalid signature from admin %s", revocation.CredentialID, revocation.RevokedBy)
+	}
+	return nil
+}
@@ -0,0 +1,110 @@
+package model
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRevocationSerializationIsUnambiguous(t *testing.T) {
+	at := time.Date(2024, 6, 1, 9, 0, 0, 0, time.UTC)
+	a := &Revocation{CredentialID: "01ABC|x", Reason: "fraud", RevokedBy: "root", RevokedAt: at}
+	b := &Revocation{CredentialID: "01ABC", Reason: "x|fraud", RevokedBy: "root", RevokedAt: at}
+	if bytes.Equal(a.Serialize(), b.Serialize()) {
+		t.Fatal("revocations with different fields serialize alike")
+	}
+
+	// The time zone a revocation is read back in must not change what was signed
+	local := *a
+	local.RevokedAt = at.In(time.FixedZone("UTC+8", 8*60*60))
+	if !bytes.Equal(a.Serialize(), local.Serialize()) {
+		t.Fatal("the same instant in another time zone serializes differently")
+	}
+}
+
+func TestRevokeCredential(t *testing.T) {
+	l := newTestLedger(t)
+	cred := l.add(t, testStudent(1), nil)
+
+	outsider := newTestAdmin(t, "outsider", "")
+	if err := l.chain.RevokeCredential(cred.ID, "fraud", outsider); err == nil {
+		t.Fatal("an admin who cannot sign for the issuer revoked a credential")
+	}
+	if err := l.chain.RevokeCredential(cred.ID, "", l.signer()); err == nil {
+		t.Fatal("a credential was revoked without a reason")
+	}
+	if err := l.chain.RevokeCredential(cred.ID, "issued in error", l.signer()); err != nil {
+		t.Fatal(err)
+	}
+	if err := l.chain.RevokeCredential(cred.ID, "again", l.superAdmin); err == nil {
+		t.Fatal("a credential was revoked twice")
+	}
+
+	report := l.chain.VerifyCredential(cred.ID)
+	expectStatus(t, report, StatusRevoked)
+	if report.Revocation == nil || report.Revocation.RevokedBy != l.signer().AdminID || report.Revocation.Reason != "issued in error" {
+		t.Fatalf("report has revocation %+v", report.Revocation)
+	}
+	var revoked *CredentialRevokedError
+	if !errors.As(report.Err(), &revoked) {
+		t.Fatalf("report error %v is not a CredentialRevokedError", report.Err())
+	}
+}
+
+func TestSuperAdminCanRevoke(t *testing.T) {
+	l := newTestLedger(t)
+	cred := l.add(t, testStudent(1), nil)
+	if err := l.chain.RevokeCredential(cred.ID, "accreditation withdrawn", l.superAdmin); err != nil {
+		t.Fatal(err)
+	}
+	expectStatus(t, l.chain.VerifyCredential(cred.ID), StatusRevoked)
+}
+
+// forgeRevocation returns a revocation of the credential signed with a key that is not registered for the claimed admin.
+func forgeRevocation(t *testing.T, credentialID, adminID string) []byte {
+	t.Helper()
+	_, key, _ := ed25519.GenerateKey(nil)
+	revocation := &Revocation{CredentialID: credentialID, Reason: "forged", RevokedBy: adminID, RevokedAt: time.Now().UTC()}
+	revocation.Signature = ed25519.Sign(key, revocation.signingHash())
+	data, err := EncodePayload(PayloadCredentialRevoked, revocation)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestForgedRevocationIsIgnored(t *testing.T) {
+	l := newTestLedger(t)
+	cred := l.add(t, testStudent(1), nil)
+
+	// A forged revocation written around the checks must neither revoke the credential nor block its real revocation
+	if err := l.chain.addBlockEntries([][]byte{forgeRevocation(t, cred.ID, l.signer().AdminID)}); err != nil {
+		t.Fatal(err)
+	}
+	if revocation, _ := l.chain.FindRevocation(cred.ID); revocation != nil {
+		t.Fatalf("forged revocation %+v was accepted", revocation)
+	}
+	expectStatus(t, l.chain.VerifyCredential(cred.ID), StatusValid)
+
+	if err := l.chain.RevokeCredential(cred.ID, "issued in error", l.signer()); err != nil {
+		t.Fatal(err)
+	}
+	report := l.chain.VerifyCredential(cred.ID)
+	expectStatus(t, report, StatusRevoked)
+	if report.Revocation.Reason != "issued in error" {
+		t.Fatalf("credential revoked for %q", report.Revocation.Reason)
+	}
+}
+
+func TestAddBlockEntriesRejectsForgedRevocation(t *testing.T) {
+	l := newTestLedger(t)
+	cred := l.add(t, testStudent(1), nil)
+	if err := l.chain.AddBlockEntries([][]byte{forgeRevocation(t, cred.ID, l.signer().AdminID)}, time.Now()); err == nil {
+		t.Fatal("a forged revocation was admitted")
+	}
+	if err := l.chain.AddBlockEntries([][]byte{forgeRevocation(t, "no-such-credential", l.superAdmin.AdminID)}, time.Now()); err == nil {
+		t.Fatal("a revocation of an unknown credential was admitted")
+	}
+}
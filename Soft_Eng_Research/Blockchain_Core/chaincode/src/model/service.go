@@ -0,0 +1,269 @@
+package model
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// LedgerService shares one credential chain and its student registry between goroutines, such as
+// HTTP handlers and the consensus layer. Writes are applied one at a time; reads run concurrently
+// against a consistent, point-in-time view of the chain.
+//
+// Once a chain is handed to a LedgerService it must only be used through the service.
+type LedgerService struct {
+	mu       sync.RWMutex
+	chain    *CredentialChain
+	students *StudentChain
+	height   int
+	appended chan struct{}
+}
+
+// NewLedgerService wraps the chain, rebuilding its student registry from the ledger.
+func NewLedgerService(chain *CredentialChain) (*LedgerService, error) {
+	if chain == nil {
+		return nil, fmt.Errorf("ledger service needs a credential chain")
+	}
+	students, err := NewStudentChain(chain)
+	if err != nil {
+		return nil, err
+	}
+	service := &LedgerService{chain: chain, students: students, height: chain.Height(), appended: make(chan struct{})}
+	service.chain.indexes()
+	service.chain.IssuerRegistry()
+	return service, nil
+}
+
+// settle brings the chain's lazily built indexes and caches, and the student registry, up to date with
+// the blocks written by the last update, so that reads never modify them. It must be called with the write lock held.
+func (s *LedgerService) settle() error {
+	s.chain.indexes()
+	s.chain.IssuerRegistry()
+	if s.chain.Height() == s.height {
+		return nil
+	}
+	s.height = s.chain.Height()
+
+	// Wake the subscriptions waiting for new blocks
+	close(s.appended)
+	s.appended = make(chan struct{})
+
+	// New blocks may register students or issue them credentials
+	return s.students.catchUp()
+}
+
+// Update runs fn as the only writer of the chain and student registry. No reads run while fn does.
+// fn must not call back into the service, and must not keep the chain or registry after it returns.
+func (s *LedgerService) Update(fn func(chain *CredentialChain, students *StudentChain) error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	err := fn(s.chain, s.students)
+	if settleErr := s.settle(); err == nil {
+		err = settleErr
+	}
+	return err
+}
+
+// View runs fn with a read view of the chain as of the moment View was called. Several views can run at once,
+// and no write is applied until they return. The view must not be used after fn returns.
+func (s *LedgerService) View(fn func(view *LedgerView) error) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return fn(&LedgerView{chain: s.chain, students: s.students})
+}
+
+// AddCredential adds a credential to the chain.
+func (s *LedgerService) AddCredential(cred *Credential) error {
+	return s.AddCredentialBatch([]*Credential{cred})
+}
+
+// AddCredentialBatch adds several credentials to the chain in a single block.
+func (s *LedgerService) AddCredentialBatch(creds []*Credential) error {
+	return s.Update(func(chain *CredentialChain, _ *StudentChain) error {
+		return chain.AddCredentialBatch(creds)
+	})
+}
+
+// RevokeCredential records the revocation of a credential.
+func (s *LedgerService) RevokeCredential(id, reason string, admin *Admin) error {
+	return s.Update(func(chain *CredentialChain, _ *StudentChain) error {
+		return chain.RevokeCredential(id, reason, admin)
+	})
+}
+
+// RegisterStudent registers a new student on the ledger as the admin.
+func (s *LedgerService) RegisterStudent(admin *Admin, id int, firstName, lastName string, birthDate time.Time, studentNum int) (*Student, error) {
+	var student *Student
+	err := s.Update(func(_ *CredentialChain, students *StudentChain) error {
+		registered, err := admin.AddNewStudent(id, firstName, lastName, birthDate, studentNum, students)
+		if err != nil {
+			return err
+		}
+		copied := *registered
+		student = &copied
+		return nil
+	})
+	return student, err
+}
+
+// AddBlockEntries appends a block holding the given entries with the agreed block time, for example one agreed on
+// by the consensus layer. The entries are checked as by CredentialChain.AddBlockEntries.
+func (s *LedgerService) AddBlockEntries(entries [][]byte, at time.Time) error {
+	return s.Update(func(chain *CredentialChain, _ *StudentChain) error {
+		return chain.AddBlockEntries(entries, at)
+	})
+}
+
+// NewIssuancePool creates an issuance pool whose proposals are checked and committed through the service,
+// so it can be shared between goroutines. Its proposals expire after ttl. Students given to Propose should be
+// copies read from a view; the registry picks up committed credentials from the ledger.
+func (s *LedgerService) NewIssuancePool(ttl time.Duration) (*IssuancePool, error) {
+	update := func(fn func(chain *CredentialChain) error) error {
+		return s.Update(func(chain *CredentialChain, _ *StudentChain) error {
+			return fn(chain)
+		})
+	}
+	return newIssuancePool(update, ttl)
+}
+
+// LedgerView is a read-only, point-in-time view of the chain handed out by LedgerService.View.
+// Everything it returns is a deep copy, so callers may keep and change results after the view is gone.
+type LedgerView struct {
+	chain    *CredentialChain
+	students *StudentChain
+}
+
+// Height returns the number of blocks in the chain.
+func (v *LedgerView) Height() int {
+	return v.chain.Height()
+}
+
+// Block returns a copy of the block with the given index.
+func (v *LedgerView) Block(index int) (Block, error) {
+	block := v.chain.block(index)
+	if block == nil {
+		return Block{}, fmt.Errorf("block %d is not in the chain", index)
+	}
+	return block.clone(), nil
+}
+
+// Validate audits the whole chain.
+func (v *LedgerView) Validate() *ValidationReport {
+	return v.chain.Validate()
+}
+
+// FindCredentialByID returns the credential with the given ID.
+func (v *LedgerView) FindCredentialByID(id string) (*Credential, error) {
+	return v.chain.FindCredentialByID(id)
+}
+
+// FindCredentialsByOwner returns every credential issued to the student with the given ID.
+func (v *LedgerView) FindCredentialsByOwner(ownerID int) []*Credential {
+	return v.chain.FindCredentialsByOwner(ownerID)
+}
+
+// FindCredentialsByIssuer returns every credential issued by the given issuer.
+func (v *LedgerView) FindCredentialsByIssuer(issuer string) []*Credential {
+	return v.chain.FindCredentialsByIssuer(issuer)
+}
+
+// FindCredentialsByType returns every credential of the given type.
+func (v *LedgerView) FindCredentialsByType(credentialType CredentialType) []*Credential {
+	return v.chain.FindCredentialsByType(credentialType)
+}
+
+// FindCredentialsIssuedBetween returns every credential issued in [from, to).
+func (v *LedgerView) FindCredentialsIssuedBetween(from, to time.Time) []*Credential {
+	return v.chain.FindCredentialsIssuedBetween(from, to)
+}
+
+// FindCredentialsRecordedBetween returns every credential in blocks created in [from, to).
+func (v *LedgerView) FindCredentialsRecordedBetween(from, to time.Time) []*Credential {
+	return v.chain.FindCredentialsRecordedBetween(from, to)
+}
+
+// CredentialHistory returns every version of the credential with the given ID, oldest first.
+func (v *LedgerView) CredentialHistory(id string) ([]*Credential, error) {
+	return v.chain.CredentialHistory(id)
+}
+
+// FindRevocation returns the revocation of the credential with the given ID, if it was revoked.
+func (v *LedgerView) FindRevocation(id string) (*Revocation, error) {
+	return v.chain.FindRevocation(id)
+}
+
+// VerifyCredential verifies the credential with the given ID as of now.
+func (v *LedgerView) VerifyCredential(id string) *VerificationReport {
+	return v.chain.VerifyCredential(id)
+}
+
+// VerifyPresentation verifies a selective disclosure presentation.
+func (v *LedgerView) VerifyPresentation(p *Presentation) *VerificationReport {
+	return v.chain.VerifyPresentation(p)
+}
+
+// VerifyDocument verifies the credential with the given ID against a presented document.
+func (v *LedgerView) VerifyDocument(id string, r io.Reader) *VerificationReport {
+	return v.chain.VerifyDocument(id, r)
+}
+
+// ProveCredential returns a proof that the credential with the given ID is included in the chain.
+func (v *LedgerView) ProveCredential(id string) (*InclusionProof, error) {
+	return v.chain.ProveCredential(id)
+}
+
+// Receipt builds the receipt of the credential with the given ID, tied to the view's checkpoint.
+func (v *LedgerView) Receipt(id string) (*Receipt, error) {
+	return v.chain.Receipt(id)
+}
+
+// Checkpoint returns the hash of the last block in the view.
+func (v *LedgerView) Checkpoint() []byte {
+	return v.chain.Checkpoint()
+}
+
+// FindIssuer returns the trusted issuer with the given identifier or name.
+func (v *LedgerView) FindIssuer(ref string) (*TrustedIssuer, error) {
+	issuer, err := v.chain.FindIssuer(ref)
+	if err != nil {
+		return nil, err
+	}
+	return issuer.clone(), nil
+}
+
+// FindStudentByID returns the student with the given internal ID.
+func (v *LedgerView) FindStudentByID(id int) (*Student, error) {
+	return copyStudent(v.students.FindStudentByID(id))
+}
+
+// FindStudentByNumber returns the student with the given student number.
+func (v *LedgerView) FindStudentByNumber(studentNum int) (*Student, error) {
+	return copyStudent(v.students.FindStudentByNumber(studentNum))
+}
+
+func copyStudent(student *Student, err error) (*Student, error) {
+	if err != nil {
+		return nil, err
+	}
+	copied := *student
+	copied.Credentials = make([]*Credential, len(student.Credentials))
+	for i, cred := range student.Credentials {
+		copied.Credentials[i] = cred.clone()
+	}
+	if student.Commitments != nil {
+		copied.Commitments = make(map[string][]byte, len(student.Commitments))
+		for claim, commitment := range student.Commitments {
+			copied.Commitments[claim] = bytes.Clone(commitment)
+		}
+	}
+	if student.Disclosures != nil {
+		copied.Disclosures = make(map[string]Disclosure, len(student.Disclosures))
+		for claim, d := range student.Disclosures {
+			d.Salt = bytes.Clone(d.Salt)
+			copied.Disclosures[claim] = d
+		}
+	}
+	return &copied, nil
+}
@@ -0,0 +1,144 @@
+package model
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+// newTestService wraps the test ledger in a service.
+func newTestService(t *testing.T, l *testLedger) *LedgerService {
+	t.Helper()
+	service, err := NewLedgerService(l.chain)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return service
+}
+
+// spoil flips the first byte of b, if it has one.
+func spoil(b []byte) {
+	if len(b) > 0 {
+		b[0] ^= 0xff
+	}
+}
+
+func TestLedgerViewReturnsDeepCopies(t *testing.T) {
+	l := newTestLedger(t)
+	service := newTestService(t, l)
+	s := testStudent(1)
+	if _, err := service.RegisterStudent(l.signer(), s.ID, s.FirstName, s.LastName, s.BirthDate, s.StudentID); err != nil {
+		t.Fatal(err)
+	}
+	expires := time.Now().Add(24 * time.Hour)
+	cred := l.issue(t, s, func(cred *Credential) { cred.ExpiresAt = &expires })
+	if err := service.AddCredential(cred); err != nil {
+		t.Fatal(err)
+	}
+
+	var checkpoint []byte
+	err := service.View(func(view *LedgerView) error {
+		checkpoint = view.Checkpoint()
+		spoil(view.Checkpoint())
+
+		block, err := view.Block(view.Height() - 1)
+		if err != nil {
+			return err
+		}
+		spoil(block.Entries[0])
+		spoil(block.Hash)
+		spoil(block.MerkleRoot)
+
+		found, err := view.FindCredentialByID(cred.ID)
+		if err != nil {
+			return err
+		}
+		spoil(found.Hash)
+		spoil(found.Signature)
+		*found.ExpiresAt = time.Time{}
+		for _, owned := range view.FindCredentialsByOwner(s.ID) {
+			spoil(owned.Hash)
+		}
+
+		issuer, err := view.FindIssuer(testIssuerName)
+		if err != nil {
+			return err
+		}
+		spoil(issuer.PublicKeys[l.signer().AdminID])
+		delete(issuer.PublicKeys, l.signer().AdminID)
+
+		student, err := view.FindStudentByID(s.ID)
+		if err != nil {
+			return err
+		}
+		if len(student.Credentials) != 1 {
+			t.Fatalf("student holds %d credentials, want 1", len(student.Credentials))
+		}
+		spoil(student.Credentials[0].Signature)
+
+		receipt, err := view.Receipt(cred.ID)
+		if err != nil {
+			return err
+		}
+		spoil(receipt.BlockHash)
+		spoil(receipt.SignerKey)
+		spoil(receipt.Inclusion.Entry)
+		spoil(receipt.Credential.Hash)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = service.View(func(view *LedgerView) error {
+		if !bytes.Equal(view.Checkpoint(), checkpoint) {
+			t.Error("changing a checkpoint changed the chain's tip")
+		}
+		if err := view.Validate().Err(); err != nil {
+			t.Errorf("changing returned blocks broke the chain: %v", err)
+		}
+		expectStatus(t, view.VerifyCredential(cred.ID), StatusValid)
+		student, err := view.FindStudentByID(s.ID)
+		if err != nil {
+			return err
+		}
+		if !bytes.Equal(student.Credentials[0].Signature, cred.Signature) {
+			t.Error("changing a student's credential changed the registry")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLedgerViewRevocationIsACopy(t *testing.T) {
+	l := newTestLedger(t)
+	service := newTestService(t, l)
+	cred := l.issue(t, testStudent(1), nil)
+	if err := service.AddCredential(cred); err != nil {
+		t.Fatal(err)
+	}
+	if err := service.RevokeCredential(cred.ID, "issued in error", l.signer()); err != nil {
+		t.Fatal(err)
+	}
+	err := service.View(func(view *LedgerView) error {
+		revocation, err := view.FindRevocation(cred.ID)
+		if err != nil {
+			return err
+		}
+		spoil(revocation.Signature)
+		revocation.Reason = "changed"
+
+		// The stored revocation must still verify, so the credential stays revoked
+		report := view.VerifyCredential(cred.ID)
+		expectStatus(t, report, StatusRevoked)
+		if report.Revocation.Reason != "issued in error" {
+			t.Errorf("revocation reason is %q after changing a copy", report.Revocation.Reason)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,221 @@
+package model
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Snapshot is the signed state of a credential chain after its first Height blocks, tied to the hash of block Height-1.
+// A chain can be bootstrapped from a snapshot and the blocks after it instead of replaying every block from genesis.
+// Credentials include revoked and superseded ones, so verification still reports them as such.
+// Students are carried as their registrations, so their details stay committed as they are on the ledger.
+type Snapshot struct {
+	Height       int                   `json:"height"`
+	BlockHash    []byte                `json:"block_hash"`
+	BlockTime    time.Time             `json:"block_time"`
+	Credentials  []SnapshotCredential  `json:"credentials"`
+	Revocations  []*Revocation         `json:"revocations"`
+	Students     []StudentRegistration `json:"students"`
+	IssuerEvents []*IssuerEvent        `json:"issuer_events"`
+	// ApprovalPolicies keep where they were recorded, since each applies only to the credentials recorded after it
+	ApprovalPolicies []SnapshotPolicy `json:"approval_policies,omitempty"`
+	SignedBy         string           `json:"signed_by"`
+	SignedAt         time.Time        `json:"signed_at"`
+	Signature        []byte           `json:"signature,omitempty"`
+}
+
+// SnapshotCredential is a credential in a snapshot together with where it was recorded on the chain.
+type SnapshotCredential struct {
+	Credential *Credential `json:"credential"`
+	BlockIndex int         `json:"block_index"`
+	Entry      int         `json:"entry"`
+	RecordedAt time.Time   `json:"recorded_at"`
+}
+
+// SnapshotPolicy is an approval policy in a snapshot together with where it was recorded on the chain.
+type SnapshotPolicy struct {
+	Policy     *ApprovalPolicy `json:"policy"`
+	BlockIndex int             `json:"block_index"`
+	Entry      int             `json:"entry"`
+}
+
+// signingHash hashes the snapshot without its signature.
+func (s *Snapshot) signingHash() ([]byte, error) {
+	unsigned := *s
+	unsigned.Signature = nil
+	data, err := json.Marshal(unsigned)
+	if err != nil {
+		return nil, err
+	}
+	hash := sha256.Sum256(data)
+	return hash[:], nil
+}
+
+// Verify checks that the snapshot is signed by one of the given super-admin keys.
+func (s *Snapshot) Verify(superAdminKeys map[string]ed25519.PublicKey) error {
+	if s.Height < 1 || len(s.BlockHash) != sha256.Size {
+		return fmt.Errorf("snapshot is not tied to a block")
+	}
+	key, ok := superAdminKeys[s.SignedBy]
+	if !ok {
+		return fmt.Errorf("snapshot signed by unknown super-admin %q", s.SignedBy)
+	}
+	hash, err := s.signingHash()
+	if err != nil {
+		return err
+	}
+	if !ed25519.Verify(key, hash, s.Signature) {
+		return fmt.Errorf("snapshot at height %d has an invalid signature", s.Height)
+	}
+	return nil
+}
+
+// height returns the number of blocks covered by the snapshot, which is zero for a chain without one.
+func (s *Snapshot) height() int {
+	if s == nil {
+		return 0
+	}
+	return s.Height
+}
+
+// anchor returns the header of the snapshot's last block, holding only its index, hash and time.
+func (s *Snapshot) anchor() *Block {
+	if s == nil {
+		return nil
+	}
+	return &Block{Version: CurrentBlockVersion, Index: s.Height - 1, Time: s.BlockTime, Hash: s.BlockHash}
+}
+
+// Snapshot captures the state of the chain after its first height blocks and signs it as the super-admin.
+func (chain *CredentialChain) Snapshot(height int, by *Admin) (*Snapshot, error) {
+	if by.Role != RoleSuperAdmin {
+		return nil, fmt.Errorf("admin %s is not a super-admin", by.AdminID)
+	}
+	if _, ok := chain.SuperAdminKeys[by.AdminID]; !ok {
+		return nil, fmt.Errorf("admin %s is not a registered super-admin", by.AdminID)
+	}
+	if len(by.PrivateKey) != ed25519.PrivateKeySize {
+		return nil, fmt.Errorf("admin %s has no signing key", by.AdminID)
+	}
+
+	base := chain.snapshot.height()
+	if height < 1 || height < base || height > chain.Height() {
+		return nil, fmt.Errorf("cannot snapshot height %d of a chain holding blocks %d to %d", height, base, chain.Height()-1)
+	}
+	tip := chain.block(height - 1)
+	if tip == nil {
+		tip = chain.snapshot.anchor()
+	}
+	tipTime, err := tip.CreatedAt()
+	if err != nil {
+		return nil, err
+	}
+
+	// Rebuild the state as of the given height from the chain's own snapshot and blocks
+	state := &CredentialChain{
+		BlockChain:     BlockChain{Blocks: chain.Blocks[:height-base], snapshot: chain.snapshot},
+		SuperAdminKeys: chain.SuperAdminKeys,
+	}
+	state.RebuildIndex()
+	idx := state.index
+
+	snapshot := &Snapshot{
+		Height:       height,
+		BlockHash:    tip.Hash,
+		BlockTime:    tipTime.UTC(),
+		Credentials:  make([]SnapshotCredential, 0, len(idx.recorded)),
+		Revocations:  make([]*Revocation, 0, len(idx.revocations)),
+		Students:     make([]StudentRegistration, 0, len(idx.registrations)),
+		IssuerEvents: idx.issuerEvents,
+		SignedBy:     by.AdminID,
+		SignedAt:     time.Now().UTC(),
+	}
+	for _, recorded := range idx.recorded {
+		entry := idx.byID[recorded.ID]
+		snapshot.Credentials = append(snapshot.Credentials, SnapshotCredential{
+			Credential: entry.Credential,
+			BlockIndex: entry.BlockIndex,
+			Entry:      entry.Entry,
+			RecordedAt: entry.RecordedAt,
+		})
+	}
+	// Only revocations whose signatures verify are carried over
+	for id := range idx.revocations {
+		if revocation, _ := state.FindRevocation(id); revocation != nil {
+			snapshot.Revocations = append(snapshot.Revocations, revocation)
+		}
+	}
+	sort.Slice(snapshot.Revocations, func(i, j int) bool {
+		return snapshot.Revocations[i].CredentialID < snapshot.Revocations[j].CredentialID
+	})
+	// Only approval policies whose signatures verify are carried over
+	for _, recorded := range idx.approvalPolicies {
+		if state.verifyApprovalPolicy(recorded.Policy) == nil {
+			snapshot.ApprovalPolicies = append(snapshot.ApprovalPolicies, SnapshotPolicy{
+				Policy:     recorded.Policy,
+				BlockIndex: recorded.BlockIndex,
+				Entry:      recorded.Entry,
+			})
+		}
+	}
+	for _, registration := range idx.registrations {
+		snapshot.Students = append(snapshot.Students, *registration)
+	}
+	sort.Slice(snapshot.Students, func(i, j int) bool {
+		return snapshot.Students[i].ID < snapshot.Students[j].ID
+	})
+
+	hash, err := snapshot.signingHash()
+	if err != nil {
+		return nil, err
+	}
+	snapshot.Signature = ed25519.Sign(by.PrivateKey, hash)
+	return snapshot, nil
+}
+
+// BootstrapCredentialChain creates an in-memory credential chain from a snapshot signed by one of the
+// super-admin keys and the blocks recorded after it.
+func BootstrapCredentialChain(snapshot *Snapshot, superAdminKeys map[string]ed25519.PublicKey, blocks []Block) (*CredentialChain, error) {
+	return bootstrapCredentialChain(snapshot, superAdminKeys, blocks, nil)
+}
+
+// OpenCredentialChainFromSnapshot reopens a store-backed credential chain from a snapshot, reading and
+// verifying only the blocks after it. New blocks are still written to the store.
+func OpenCredentialChainFromSnapshot(snapshot *Snapshot, superAdminKeys map[string]ed25519.PublicKey, store *LedgerStore) (*CredentialChain, error) {
+	if store.Len() < snapshot.Height {
+		return nil, fmt.Errorf("ledger store holds %d blocks, the snapshot covers %d", store.Len(), snapshot.Height)
+	}
+	last, err := store.ReadBlock(snapshot.Height - 1)
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(last.Hash, snapshot.BlockHash) {
+		return nil, fmt.Errorf("snapshot does not match block %d of the ledger store", snapshot.Height-1)
+	}
+	blocks, err := store.LoadBlocksFrom(snapshot.Height)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load ledger: %w", err)
+	}
+	return bootstrapCredentialChain(snapshot, superAdminKeys, blocks, store)
+}
+
+func bootstrapCredentialChain(snapshot *Snapshot, superAdminKeys map[string]ed25519.PublicKey, blocks []Block, store *LedgerStore) (*CredentialChain, error) {
+	if err := snapshot.Verify(superAdminKeys); err != nil {
+		return nil, err
+	}
+	if err := validateBlocks(blocks, snapshot.anchor()).Err(); err != nil {
+		return nil, fmt.Errorf("blocks do not continue the snapshot: %w", err)
+	}
+
+	chain := &CredentialChain{
+		BlockChain:     BlockChain{Blocks: blocks, store: store, snapshot: snapshot},
+		SuperAdminKeys: superAdminKeys,
+	}
+	chain.RebuildIndex()
+	return chain, nil
+}
@@ -0,0 +1,195 @@
+package model
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+// superAdminKeys returns the super-admin keys of the test ledger.
+func (l *testLedger) superAdminKeys() map[string]ed25519.PublicKey {
+	return map[string]ed25519.PublicKey{l.superAdmin.AdminID: l.superAdmin.PublicKey}
+}
+
+// bootstrap snapshots the test ledger at the given height and bootstraps a new chain from the snapshot
+// and the blocks after it.
+func (l *testLedger) bootstrap(t *testing.T, height int) (*Snapshot, *CredentialChain) {
+	t.Helper()
+	snapshot, err := l.chain.Snapshot(height, l.superAdmin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	chain, err := BootstrapCredentialChain(snapshot, l.superAdminKeys(), slices.Clone(l.chain.Blocks[height:]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return snapshot, chain
+}
+
+func TestBootstrapFromSnapshot(t *testing.T) {
+	l := newTestLedger(t)
+	s := testStudent(1)
+	kept := l.add(t, s, nil)
+	revoked := l.add(t, s, nil)
+	if err := l.chain.RevokeCredential(revoked.ID, "issued in error", l.signer()); err != nil {
+		t.Fatal(err)
+	}
+	replaced := l.add(t, s, nil)
+	if err := l.chain.SupersedeCredential(replaced.ID, l.reissue(t, s, replaced.ID)); err != nil {
+		t.Fatal(err)
+	}
+	l.requireQuorum(t, 2)
+	height := l.chain.Height()
+	after := l.add(t, s, func(cred *Credential) { cred.Type = Diploma })
+
+	_, chain := l.bootstrap(t, height)
+	if chain.Height() != l.chain.Height() || !bytes.Equal(chain.Checkpoint(), l.chain.Checkpoint()) {
+		t.Fatal("bootstrapped chain does not end where the ledger does")
+	}
+	if err := chain.Validate().Err(); err != nil {
+		t.Fatal(err)
+	}
+	expectStatus(t, chain.VerifyCredential(kept.ID), StatusValid)
+	expectStatus(t, chain.VerifyCredential(revoked.ID), StatusRevoked)
+	expectStatus(t, chain.VerifyCredential(replaced.ID), StatusSuperseded)
+	expectStatus(t, chain.VerifyCredential(after.ID), StatusValid)
+	if len(chain.FindCredentialsByOwner(s.ID)) != 5 {
+		t.Errorf("bootstrapped chain holds %d of the student's credentials, want 5", len(chain.FindCredentialsByOwner(s.ID)))
+	}
+
+	// The issuer registry and approval policies recorded before the snapshot still apply
+	if err := chain.AddCredentialModel(l.issue(t, s, nil)); err == nil {
+		t.Error("the quorum recorded before the snapshot was not enforced")
+	}
+	if err := chain.AddCredentialModel(l.issue(t, s, func(cred *Credential) { cred.Type = Diploma })); err != nil {
+		t.Errorf("bootstrapped chain refused a credential of the snapshot's issuer: %v", err)
+	}
+}
+
+func TestSnapshotCarriesStudentRegistrations(t *testing.T) {
+	l := newTestLedger(t)
+	students, err := NewStudentChain(l.chain)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := testStudent(1)
+	if _, err := l.signer().AddNewStudent(s.ID, s.FirstName, s.LastName, s.BirthDate, s.StudentID, students); err != nil {
+		t.Fatal(err)
+	}
+
+	snapshot, chain := l.bootstrap(t, l.chain.Height())
+	if len(snapshot.Students) != 1 || snapshot.Students[0].Student != nil || len(snapshot.Students[0].Commitments) == 0 {
+		t.Fatalf("snapshot students are %+v, want one committed registration", snapshot.Students)
+	}
+	if data := mustJSON(t, snapshot); bytes.Contains(data, []byte(s.LastName)) {
+		t.Fatal("snapshot reveals the student's details")
+	}
+
+	restored, err := NewStudentChain(chain)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := restored.FindStudentByID(s.ID); err != nil {
+		t.Fatalf("bootstrapped registry lost the student: %v", err)
+	}
+	if _, err := l.signer().AddNewStudent(s.ID, "Grace", "Hopper", s.BirthDate, s.StudentID+1, restored); err == nil {
+		t.Fatal("a student registered before the snapshot was registered again")
+	}
+}
+
+func TestSnapshotSignature(t *testing.T) {
+	l := newTestLedger(t)
+	cred := l.add(t, testStudent(1), nil)
+	height := l.chain.Height()
+
+	tests := map[string]func(s *Snapshot){
+		"changed credential":  func(s *Snapshot) { s.Credentials[0].Credential.OwnerID = 2 },
+		"dropped credentials": func(s *Snapshot) { s.Credentials = nil },
+		"moved block":         func(s *Snapshot) { s.BlockHash = bytes.Repeat([]byte{1}, len(s.BlockHash)) },
+		"unknown signer":      func(s *Snapshot) { s.SignedBy = "someone" },
+		"unsigned":            func(s *Snapshot) { s.Signature = nil },
+	}
+	for name, change := range tests {
+		t.Run(name, func(t *testing.T) {
+			snapshot, err := l.chain.Snapshot(height, l.superAdmin)
+			if err != nil {
+				t.Fatal(err)
+			}
+			change(snapshot)
+			if _, err := BootstrapCredentialChain(snapshot, l.superAdminKeys(), nil); err == nil {
+				t.Fatal("chain was bootstrapped from a changed snapshot")
+			}
+		})
+	}
+
+	snapshot, err := l.chain.Snapshot(height, l.superAdmin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if snapshot.Credentials[0].Credential.ID != cred.ID {
+		t.Fatalf("snapshot holds credential %s, want %s", snapshot.Credentials[0].Credential.ID, cred.ID)
+	}
+	if _, err := l.chain.Snapshot(height, l.signer()); err == nil {
+		t.Error("an admin who is not a super-admin signed a snapshot")
+	}
+	if _, err := l.chain.Snapshot(height+1, l.superAdmin); err == nil {
+		t.Error("a snapshot was taken past the end of the chain")
+	}
+}
+
+func TestBootstrapRefusesBlocksThatDoNotContinue(t *testing.T) {
+	l := newTestLedger(t)
+	l.add(t, testStudent(1), nil)
+	height := l.chain.Height()
+	l.add(t, testStudent(1), nil)
+	l.add(t, testStudent(1), nil)
+
+	snapshot, err := l.chain.Snapshot(height, l.superAdmin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Skipping a block breaks the link to the snapshot
+	if _, err := BootstrapCredentialChain(snapshot, l.superAdminKeys(), slices.Clone(l.chain.Blocks[height+1:])); err == nil {
+		t.Fatal("chain was bootstrapped from blocks that do not follow the snapshot")
+	}
+}
+
+func TestOpenCredentialChainFromSnapshot(t *testing.T) {
+	l := newTestLedger(t)
+	cred := l.add(t, testStudent(1), nil)
+	snapshot, err := l.chain.Snapshot(l.chain.Height(), l.superAdmin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	later := l.add(t, testStudent(1), nil)
+
+	store, err := OpenLedgerStore(filepath.Join(t.TempDir(), "store"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	if _, err := ImportChain(slices.Clone(l.chain.Blocks), store); err != nil {
+		t.Fatal(err)
+	}
+
+	chain, err := OpenCredentialChainFromSnapshot(snapshot, l.superAdminKeys(), store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(chain.Blocks) != 1 {
+		t.Errorf("reopened chain read %d blocks, want only the one after the snapshot", len(chain.Blocks))
+	}
+	expectStatus(t, chain.VerifyCredential(cred.ID), StatusValid)
+	expectStatus(t, chain.VerifyCredential(later.ID), StatusValid)
+
+	other := newTestLedger(t)
+	otherSnapshot, err := other.chain.Snapshot(other.chain.Height(), other.superAdmin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := OpenCredentialChainFromSnapshot(otherSnapshot, other.superAdminKeys(), store); err == nil {
+		t.Fatal("a store was opened from another chain's snapshot")
+	}
+}
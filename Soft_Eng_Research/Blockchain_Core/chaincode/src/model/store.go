@@ -0,0 +1,325 @@
+package model
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+const (
+	// DefaultMaxSegmentSize is the size after which the store rolls over to a new segment file.
+	DefaultMaxSegmentSize = 16 << 20
+
+	indexFileName     = "blocks.idx"
+	segmentNameFormat = "segment-%06d.log"
+
+	// recordHeaderSize is the length and CRC32 prefix written before each block.
+	recordHeaderSize = 8
+	// indexEntrySize is the segment number, offset and record length of one block.
+	indexEntrySize = 16
+)
+
+// indexEntry locates a single block record inside the segment files.
+type indexEntry struct {
+	Segment uint32
+	Offset  uint64
+	Length  uint32
+}
+
+// LedgerStore is an append-only, on-disk store for blocks.
+// Blocks are written to numbered segment files and located through a fixed-size block index,
+// so the position of a block in the index is its block index.
+type LedgerStore struct {
+	MaxSegmentSize int64
+
+	dir         string
+	index       *os.File
+	segment     *os.File
+	segmentID   uint32
+	segmentSize int64
+	entries     []indexEntry
+}
+
+// OpenLedgerStore opens the ledger store in dir, creating it if it does not exist.
+// A partially written block left behind by a crash is discarded.
+func OpenLedgerStore(dir string) (*LedgerStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create ledger directory: %w", err)
+	}
+
+	index, err := os.OpenFile(filepath.Join(dir, indexFileName), os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open block index: %w", err)
+	}
+
+	store := &LedgerStore{
+		MaxSegmentSize: DefaultMaxSegmentSize,
+		dir:            dir,
+		index:          index,
+	}
+
+	if err := store.loadIndex(); err != nil {
+		index.Close()
+		return nil, err
+	}
+	if err := store.openActiveSegment(); err != nil {
+		index.Close()
+		return nil, err
+	}
+	return store, nil
+}
+
+// loadIndex reads every entry of the block index into memory.
+func (s *LedgerStore) loadIndex() error {
+	info, err := s.index.Stat()
+	if err != nil {
+		return fmt.Errorf("failed to stat block index: %w", err)
+	}
+
+	// Drop a trailing entry that was only partially written
+	size := info.Size() - info.Size()%indexEntrySize
+	if size != info.Size() {
+		if err := s.index.Truncate(size); err != nil {
+			return fmt.Errorf("failed to truncate block index: %w", err)
+		}
+	}
+
+	buf := make([]byte, size)
+	if _, err := s.index.ReadAt(buf, 0); err != nil && err != io.EOF {
+		return fmt.Errorf("failed to read block index: %w", err)
+	}
+
+	s.entries = parseIndex(buf)
+
+	if _, err := s.index.Seek(size, io.SeekStart); err != nil {
+		return fmt.Errorf("failed to seek block index: %w", err)
+	}
+	return nil
+}
+
+// parseIndex decodes the complete entries of a block index, ignoring a trailing partial entry.
+func parseIndex(buf []byte) []indexEntry {
+	entries := make([]indexEntry, 0, len(buf)/indexEntrySize)
+	for off := 0; off+indexEntrySize <= len(buf); off += indexEntrySize {
+		entries = append(entries, indexEntry{
+			Segment: binary.BigEndian.Uint32(buf[off:]),
+			Offset:  binary.BigEndian.Uint64(buf[off+4:]),
+			Length:  binary.BigEndian.Uint32(buf[off+12:]),
+		})
+	}
+	return entries
+}
+
+// ReadLedgerStore reads every block of the ledger store in dir without opening it for writing.
+// Nothing on disk is created or repaired: a missing store is an error, and a block left partially
+// written by a crash is skipped rather than truncated.
+func ReadLedgerStore(dir string) ([]Block, error) {
+	buf, err := os.ReadFile(filepath.Join(dir, indexFileName))
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("%s is not a ledger store: it has no block index", dir)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read block index: %w", err)
+	}
+	store := &LedgerStore{dir: dir, entries: parseIndex(buf)}
+	return store.LoadBlocks()
+}
+
+// openActiveSegment opens the segment that new blocks are appended to.
+// Bytes after the last indexed record are discarded, since the block they belong to was never indexed.
+func (s *LedgerStore) openActiveSegment() error {
+	var end int64
+	if len(s.entries) > 0 {
+		last := s.entries[len(s.entries)-1]
+		s.segmentID = last.Segment
+		end = int64(last.Offset) + recordHeaderSize + int64(last.Length)
+	}
+
+	segment, err := os.OpenFile(s.segmentPath(s.segmentID), os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to open segment %d: %w", s.segmentID, err)
+	}
+
+	info, err := segment.Stat()
+	if err != nil {
+		segment.Close()
+		return fmt.Errorf("failed to stat segment %d: %w", s.segmentID, err)
+	}
+	if info.Size() < end {
+		segment.Close()
+		return fmt.Errorf("segment %d is shorter than its indexed blocks", s.segmentID)
+	}
+	if info.Size() > end {
+		if err := segment.Truncate(end); err != nil {
+			segment.Close()
+			return fmt.Errorf("failed to truncate segment %d: %w", s.segmentID, err)
+		}
+	}
+
+	s.segment = segment
+	s.segmentSize = end
+	return nil
+}
+
+func (s *LedgerStore) segmentPath(id uint32) string {
+	return filepath.Join(s.dir, fmt.Sprintf(segmentNameFormat, id))
+}
+
+// Len returns the number of blocks in the store.
+func (s *LedgerStore) Len() int {
+	return len(s.entries)
+}
+
+// Append writes a block to the end of the store and syncs it to disk.
+// The block's index must be the next one in the store.
+func (s *LedgerStore) Append(b *Block) error {
+	if b.Index != len(s.entries) {
+		return fmt.Errorf("cannot append block %d, store expects block %d", b.Index, len(s.entries))
+	}
+
+	data, err := b.Serialize()
+	if err != nil {
+		return fmt.Errorf("failed to serialize block %d: %w", b.Index, err)
+	}
+
+	// Roll over to a new segment once the active one is full
+	if s.segmentSize > 0 && s.segmentSize+recordHeaderSize+int64(len(data)) > s.MaxSegmentSize {
+		if err := s.segment.Close(); err != nil {
+			return fmt.Errorf("failed to close segment %d: %w", s.segmentID, err)
+		}
+		segment, err := os.OpenFile(s.segmentPath(s.segmentID+1), os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0o644)
+		if err != nil {
+			return fmt.Errorf("failed to create segment %d: %w", s.segmentID+1, err)
+		}
+		s.segment = segment
+		s.segmentID++
+		s.segmentSize = 0
+	}
+
+	// Write the block record and make it durable before it is indexed
+	record := make([]byte, recordHeaderSize+len(data))
+	binary.BigEndian.PutUint32(record[0:], uint32(len(data)))
+	binary.BigEndian.PutUint32(record[4:], crc32.ChecksumIEEE(data))
+	copy(record[recordHeaderSize:], data)
+
+	if _, err := s.segment.WriteAt(record, s.segmentSize); err != nil {
+		return fmt.Errorf("failed to write block %d: %w", b.Index, err)
+	}
+	if err := s.segment.Sync(); err != nil {
+		return fmt.Errorf("failed to sync segment %d: %w", s.segmentID, err)
+	}
+
+	entry := indexEntry{Segment: s.segmentID, Offset: uint64(s.segmentSize), Length: uint32(len(data))}
+	buf := make([]byte, indexEntrySize)
+	binary.BigEndian.PutUint32(buf[0:], entry.Segment)
+	binary.BigEndian.PutUint64(buf[4:], entry.Offset)
+	binary.BigEndian.PutUint32(buf[12:], entry.Length)
+
+	if _, err := s.index.Write(buf); err != nil {
+		return fmt.Errorf("failed to index block %d: %w", b.Index, err)
+	}
+	if err := s.index.Sync(); err != nil {
+		return fmt.Errorf("failed to sync block index: %w", err)
+	}
+
+	s.segmentSize += int64(len(record))
+	s.entries = append(s.entries, entry)
+	return nil
+}
+
+// ReadBlock reads the block with the given index from disk.
+func (s *LedgerStore) ReadBlock(i int) (*Block, error) {
+	if i < 0 || i >= len(s.entries) {
+		return nil, fmt.Errorf("block %d is not in the store", i)
+	}
+	entry := s.entries[i]
+
+	segment, err := os.Open(s.segmentPath(entry.Segment))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open segment %d: %w", entry.Segment, err)
+	}
+	defer segment.Close()
+
+	return readRecord(segment, i, entry)
+}
+
+// LoadBlocks reads every block in the store in index order.
+func (s *LedgerStore) LoadBlocks() ([]Block, error) {
+	return s.LoadBlocksFrom(0)
+}
+
+// LoadBlocksFrom reads the blocks in the store from index start onwards, in index order.
+func (s *LedgerStore) LoadBlocksFrom(start int) ([]Block, error) {
+	if start < 0 || start > len(s.entries) {
+		return nil, fmt.Errorf("block %d is not in the store", start)
+	}
+	blocks := make([]Block, 0, len(s.entries)-start)
+
+	var segment *os.File
+	defer func() {
+		if segment != nil {
+			segment.Close()
+		}
+	}()
+
+	for i := start; i < len(s.entries); i++ {
+		entry := s.entries[i]
+		// Segments are written in order, so only switch files when the segment number changes
+		if segment == nil || segment.Name() != s.segmentPath(entry.Segment) {
+			if segment != nil {
+				segment.Close()
+			}
+			var err error
+			segment, err = os.Open(s.segmentPath(entry.Segment))
+			if err != nil {
+				return nil, fmt.Errorf("failed to open segment %d: %w", entry.Segment, err)
+			}
+		}
+
+		block, err := readRecord(segment, i, entry)
+		if err != nil {
+			return nil, err
+		}
+		blocks = append(blocks, *block)
+	}
+	return blocks, nil
+}
+
+// readRecord reads and decodes the block record described by entry.
+func readRecord(segment *os.File, i int, entry indexEntry) (*Block, error) {
+	record := make([]byte, recordHeaderSize+int(entry.Length))
+	if _, err := segment.ReadAt(record, int64(entry.Offset)); err != nil {
+		return nil, fmt.Errorf("failed to read block %d: %w", i, err)
+	}
+
+	length := binary.BigEndian.Uint32(record[0:])
+	checksum := binary.BigEndian.Uint32(record[4:])
+	data := record[recordHeaderSize:]
+
+	if length != entry.Length {
+		return nil, fmt.Errorf("block %d: record length %d does not match index length %d", i, length, entry.Length)
+	}
+	if crc32.ChecksumIEEE(data) != checksum {
+		return nil, fmt.Errorf("block %d: checksum mismatch", i)
+	}
+
+	var block Block
+	if err := json.Unmarshal(data, &block); err != nil {
+		return nil, fmt.Errorf("block %d: failed to decode: %w", i, err)
+	}
+	return &block, nil
+}
+
+// Close closes the segment and index files.
+func (s *LedgerStore) Close() error {
+	segErr := s.segment.Close()
+	idxErr := s.index.Close()
+	if segErr != nil {
+		return segErr
+	}
+	return idxErr
+}
@@ -0,0 +1,254 @@
+package model
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// newStoredChain opens a ledger store in dir and adds blocks opaque blocks after the genesis block.
+func newStoredChain(t *testing.T, dir string, blocks int) (*BlockChain, *LedgerStore) {
+	t.Helper()
+	store, err := OpenLedgerStore(dir)
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	chain, err := NewBlockChain(store)
+	if err != nil {
+		store.Close()
+		t.Fatalf("open chain: %v", err)
+	}
+	for i := 0; i < blocks; i++ {
+		if err := chain.AddBlock([]byte(fmt.Sprintf("block data %d", i))); err != nil {
+			store.Close()
+			t.Fatalf("add block %d: %v", i, err)
+		}
+	}
+	return chain, store
+}
+
+func appendToFile(t *testing.T, path string, data []byte) {
+	t.Helper()
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	if _, err := file.Write(data); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func fileSize(t *testing.T, path string) int64 {
+	t.Helper()
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return info.Size()
+}
+
+func TestLedgerStoreRecoversFromPartialWrite(t *testing.T) {
+	tests := []struct {
+		name string
+		// crash leaves behind what a write interrupted after the store was closed would have
+		crash func(t *testing.T, dir string)
+	}{
+		{
+			name: "partial index entry",
+			crash: func(t *testing.T, dir string) {
+				appendToFile(t, filepath.Join(dir, indexFileName), make([]byte, indexEntrySize/2))
+			},
+		},
+		{
+			name: "unindexed segment record",
+			crash: func(t *testing.T, dir string) {
+				appendToFile(t, filepath.Join(dir, fmt.Sprintf(segmentNameFormat, 0)), []byte("record that was never indexed"))
+			},
+		},
+		{
+			name: "both",
+			crash: func(t *testing.T, dir string) {
+				appendToFile(t, filepath.Join(dir, fmt.Sprintf(segmentNameFormat, 0)), []byte("record that was never indexed"))
+				appendToFile(t, filepath.Join(dir, indexFileName), make([]byte, indexEntrySize-1))
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			chain, store := newStoredChain(t, dir, 3)
+			want := chain.Blocks
+			segmentPath := filepath.Join(dir, fmt.Sprintf(segmentNameFormat, 0))
+			segmentEnd := fileSize(t, segmentPath)
+			if err := store.Close(); err != nil {
+				t.Fatal(err)
+			}
+
+			tt.crash(t, dir)
+
+			store, err := OpenLedgerStore(dir)
+			if err != nil {
+				t.Fatalf("reopen store: %v", err)
+			}
+			defer store.Close()
+
+			if store.Len() != len(want) {
+				t.Fatalf("store holds %d blocks after recovery, want %d", store.Len(), len(want))
+			}
+			if size := fileSize(t, filepath.Join(dir, indexFileName)); size != int64(len(want)*indexEntrySize) {
+				t.Errorf("block index is %d bytes after recovery, want %d", size, len(want)*indexEntrySize)
+			}
+			if size := fileSize(t, segmentPath); size != segmentEnd {
+				t.Errorf("segment is %d bytes after recovery, want %d", size, segmentEnd)
+			}
+
+			reopened, err := NewBlockChain(store)
+			if err != nil {
+				t.Fatalf("reopen chain: %v", err)
+			}
+			for i := range want {
+				if !bytes.Equal(reopened.Blocks[i].Hash, want[i].Hash) {
+					t.Fatalf("block %d hash changed after recovery", i)
+				}
+			}
+
+			// The store must accept new blocks where the last complete one ended
+			if err := reopened.AddBlock([]byte("after recovery")); err != nil {
+				t.Fatalf("add block after recovery: %v", err)
+			}
+			blocks, err := store.LoadBlocks()
+			if err != nil {
+				t.Fatalf("load blocks: %v", err)
+			}
+			if len(blocks) != len(want)+1 {
+				t.Fatalf("loaded %d blocks, want %d", len(blocks), len(want)+1)
+			}
+			if err := ValidateBlocks(blocks).Err(); err != nil {
+				t.Fatalf("recovered ledger is invalid: %v", err)
+			}
+		})
+	}
+}
+
+func TestLedgerStoreSegmentRollover(t *testing.T) {
+	dir := t.TempDir()
+	store, err := OpenLedgerStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Every block is larger than this, so each one after the first goes to a new segment
+	store.MaxSegmentSize = 64
+	chain, err := NewBlockChain(store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 4; i++ {
+		if err := chain.AddBlock([]byte(fmt.Sprintf("block data %d", i))); err != nil {
+			t.Fatalf("add block %d: %v", i, err)
+		}
+	}
+	want := chain.Blocks
+	if err := store.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	segments, err := filepath.Glob(filepath.Join(dir, "segment-*.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(segments) != len(want) {
+		t.Fatalf("store wrote %d segments, want one per block (%d)", len(segments), len(want))
+	}
+
+	store, err = OpenLedgerStore(dir)
+	if err != nil {
+		t.Fatalf("reopen store: %v", err)
+	}
+	defer store.Close()
+	if store.segmentID != uint32(len(want)-1) {
+		t.Errorf("reopened store appends to segment %d, want the last segment %d", store.segmentID, len(want)-1)
+	}
+
+	for i := range want {
+		block, err := store.ReadBlock(i)
+		if err != nil {
+			t.Fatalf("read block %d: %v", i, err)
+		}
+		if !bytes.Equal(block.Hash, want[i].Hash) {
+			t.Errorf("block %d read from segment %d has the wrong hash", i, store.entries[i].Segment)
+		}
+	}
+
+	// Loading from the middle must start reading at the right segment
+	blocks, err := store.LoadBlocksFrom(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(blocks) != len(want)-2 || !bytes.Equal(blocks[0].Hash, want[2].Hash) {
+		t.Fatalf("loading from block 2 returned %d blocks starting at the wrong block", len(blocks))
+	}
+
+	// With the default segment size the next block fits in the last segment
+	reopened, err := NewBlockChain(store)
+	if err != nil {
+		t.Fatalf("reopen chain: %v", err)
+	}
+	if err := reopened.AddBlock([]byte("after reopening")); err != nil {
+		t.Fatal(err)
+	}
+	if last := store.entries[len(store.entries)-1]; last.Segment != uint32(len(want)-1) {
+		t.Errorf("block appended after reopening went to segment %d, want %d", last.Segment, len(want)-1)
+	}
+	all, err := store.LoadBlocks()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ValidateBlocks(all).Err(); err != nil {
+		t.Fatalf("ledger spanning several segments is invalid: %v", err)
+	}
+}
+
+func TestReadLedgerStoreDoesNotWrite(t *testing.T) {
+	t.Run("missing store", func(t *testing.T) {
+		dir := t.TempDir()
+		if _, err := ReadLedgerStore(dir); err == nil {
+			t.Fatal("reading an empty directory succeeded")
+		}
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(entries) != 0 {
+			t.Fatalf("reading an empty directory created %d files", len(entries))
+		}
+	})
+
+	t.Run("partial write", func(t *testing.T) {
+		dir := t.TempDir()
+		chain, store := newStoredChain(t, dir, 2)
+		want := chain.Blocks
+		if err := store.Close(); err != nil {
+			t.Fatal(err)
+		}
+		indexPath := filepath.Join(dir, indexFileName)
+		segmentPath := filepath.Join(dir, fmt.Sprintf(segmentNameFormat, 0))
+		appendToFile(t, indexPath, make([]byte, indexEntrySize/2))
+		appendToFile(t, segmentPath, []byte("record that was never indexed"))
+		indexSize, segmentSize := fileSize(t, indexPath), fileSize(t, segmentPath)
+
+		blocks, err := ReadLedgerStore(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(blocks) != len(want) {
+			t.Fatalf("read %d blocks, want %d", len(blocks), len(want))
+		}
+		if fileSize(t, indexPath) != indexSize || fileSize(t, segmentPath) != segmentSize {
+			t.Fatal("reading the store truncated its files")
+		}
+	})
+}
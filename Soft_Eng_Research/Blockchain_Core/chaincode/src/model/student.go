@@ -2,7 +2,9 @@ package model
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
+	"strconv"
 	"time"
 )
 
@@ -13,14 +15,232 @@ type Student struct {
 	BirthDate   time.Time     `json:"birth_date"`
 	StudentID   int           `json:"student_id"`
 	Credentials []*Credential `json:"credentials,omitempty"`
+	// Commitments are the salted commitments to the student's details recorded by their registration
+	Commitments map[string][]byte `json:"commitments,omitempty"`
+	// Disclosures reveal the committed details; they are kept by the registry, never written to the ledger
+	Disclosures map[string]Disclosure `json:"disclosures,omitempty"`
 }
 
+// birthDateLayout is the format of the birth_date claim.
+const birthDateLayout = "2006-01-02"
+
+// StudentChain is the registry of students, keyed by their internal ID.
+// A StudentChain created with NewStudentChain is rebuilt from, and records new registrations on, a ledger.
 type StudentChain struct {
 	Students map[int]*Student
+	byNumber map[int]*Student
+	ledger   *CredentialChain
+	// applied is the number of ledger blocks replayed into the registry
+	applied int
+}
+
+// StudentRegistration is the ledger event recording that a student was registered. The student's details are
+// not recorded in clear: the registration holds a salted commitment to each of the StudentClaims, and the
+// disclosures that reveal them stay with the registry, so the holder can share them selectively.
+type StudentRegistration struct {
+	ID           int               `json:"user_id"`
+	Commitments  map[string][]byte `json:"commitments,omitempty"`
+	RegisteredBy string            `json:"registered_by"`
+	RegisteredAt time.Time         `json:"registered_at"`
+	// Student holds the details of registrations written before they were committed (payload version 1).
+	// New registrations must leave it unset.
+	Student *Student `json:"student,omitempty"`
+}
+
+// decodeStudentRegistrationV1 decodes a version 1 registration, which recorded the student's details in clear.
+func decodeStudentRegistrationV1(payload []byte) (interface{}, error) {
+	var registration StudentRegistration
+	if err := json.Unmarshal(payload, &registration); err != nil {
+		return nil, err
+	}
+	if registration.Student == nil {
+		return nil, fmt.Errorf("registration has no student")
+	}
+	registration.ID = registration.Student.ID
+	return &registration, nil
+}
+
+// student returns the registered student, with their details only if the registration recorded them in clear.
+func (r *StudentRegistration) student() *Student {
+	if r.Student != nil {
+		student := *r.Student
+		student.Credentials = nil
+		return &student
+	}
+	return &Student{ID: r.ID, Commitments: r.Commitments}
+}
+
+// StudentClaims returns the student's details as the claims committed by their registration,
+// and by credentials that let the holder disclose them.
+func StudentClaims(s *Student) map[string]string {
+	return map[string]string{
+		"first_name":     s.FirstName,
+		"last_name":      s.LastName,
+		"birth_date":     s.BirthDate.Format(birthDateLayout),
+		"student_number": strconv.Itoa(s.StudentID),
+	}
+}
+
+// setDetail sets the student detail named by one of the StudentClaims from its disclosed value.
+func (s *Student) setDetail(claim, value string) error {
+	switch claim {
+	case "first_name":
+		s.FirstName = value
+	case "last_name":
+		s.LastName = value
+	case "birth_date":
+		birthDate, err := time.Parse(birthDateLayout, value)
+		if err != nil {
+			return fmt.Errorf("invalid birth date %q: %w", value, err)
+		}
+		s.BirthDate = birthDate
+	case "student_number":
+		number, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("invalid student number %q: %w", value, err)
+		}
+		s.StudentID = number
+	default:
+		return fmt.Errorf("unknown student claim %q", claim)
+	}
+	return nil
+}
+
+// NewStudentChain creates a student registry backed by ledger, rebuilt by replaying its events.
+func NewStudentChain(ledger *CredentialChain) (*StudentChain, error) {
+	chain := &StudentChain{ledger: ledger}
+	if err := chain.Replay(); err != nil {
+		return nil, err
+	}
+	return chain, nil
+}
+
+// Replay rebuilds the registry from the ledger's student registrations and attaches
+// each issued credential to the student who owns it. A ledger bootstrapped from a snapshot
+// is replayed from the snapshot's students and credentials onwards.
+func (chain *StudentChain) Replay() error {
+	if chain.ledger == nil {
+		return fmt.Errorf("student chain has no ledger to replay")
+	}
+
+	chain.Students = make(map[int]*Student)
+	chain.byNumber = make(map[int]*Student)
+	chain.applied = 0
+
+	if snapshot := chain.ledger.snapshot; snapshot != nil {
+		for i := range snapshot.Students {
+			chain.addStudent(snapshot.Students[i].student())
+		}
+		for _, entry := range snapshot.Credentials {
+			if owner, ok := chain.Students[entry.Credential.OwnerID]; ok {
+				cred := *entry.Credential
+				owner.Credentials = append(owner.Credentials, &cred)
+			}
+		}
+	}
+	return chain.catchUp()
+}
+
+// catchUp applies the ledger blocks appended since the registry was last brought up to date.
+func (chain *StudentChain) catchUp() error {
+	if chain.ledger == nil {
+		return nil
+	}
+	for ; chain.applied < len(chain.ledger.Blocks); chain.applied++ {
+		block := &chain.ledger.Blocks[chain.applied]
+		for _, payload := range block.Payloads() {
+			_, value, err := DecodePayload(payload)
+			if err != nil {
+				continue
+			}
+			switch event := value.(type) {
+			case *StudentRegistration:
+				// The first registration of an ID stands, as in the ledger index; the ledger refuses
+				// later ones, but blocks written without its checks may still hold them
+				if _, exists := chain.Students[event.ID]; exists {
+					continue
+				}
+				chain.addStudent(event.student())
+			case *Credential:
+				// The issuing admin may already have given the student the credential
+				if owner, ok := chain.Students[event.OwnerID]; ok && !owner.hasCredential(event.ID) {
+					owner.Credentials = append(owner.Credentials, event)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// hasCredential reports whether the student holds a credential with the given ID.
+func (s *Student) hasCredential(id string) bool {
+	for _, cred := range s.Credentials {
+		if cred.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+// addStudent adds the student to the registry, and to its student number lookup once the number is known.
+func (chain *StudentChain) addStudent(student *Student) {
+	if chain.Students == nil {
+		chain.Students = make(map[int]*Student)
+	}
+	chain.Students[student.ID] = student
+	chain.addStudentNumber(student)
+}
+
+func (chain *StudentChain) addStudentNumber(student *Student) {
+	if chain.byNumber == nil {
+		chain.byNumber = make(map[int]*Student)
+	}
+	if _, exists := chain.byNumber[student.StudentID]; !exists && student.StudentID != 0 {
+		chain.byNumber[student.StudentID] = student
+	}
 }
 
-// AddCredential adds a new credential to the student's list of non-academic credentials
-func (s *Student) AddCredential(credentialType CredentialType, issuer string, dataIssued time.Time) bool {
+// RestoreStudentDetails fills in the details of a registered student from the disclosures made when they were
+// registered, which the ledger does not hold, for example after the registry was rebuilt by replaying the ledger.
+// Every disclosure must match the registration's commitment to its claim.
+func (chain *StudentChain) RestoreStudentDetails(id int, disclosures map[string]Disclosure) error {
+	student, err := chain.FindStudentByID(id)
+	if err != nil {
+		return err
+	}
+	presented := make([]Disclosure, 0, len(disclosures))
+	for claim, d := range disclosures {
+		if d.Claim != claim {
+			return fmt.Errorf("disclosure of claim %q is filed under %q", d.Claim, claim)
+		}
+		presented = append(presented, d)
+	}
+	disclosed, err := checkDisclosures(student.Commitments, presented)
+	if err != nil {
+		return fmt.Errorf("student %d: %w", id, err)
+	}
+
+	details := *student
+	for claim, value := range disclosed {
+		if err := details.setDetail(claim, value); err != nil {
+			return err
+		}
+	}
+	if other, ok := chain.byNumber[details.StudentID]; ok && other != student {
+		return fmt.Errorf("student number %d is already registered", details.StudentID)
+	}
+	details.Disclosures = make(map[string]Disclosure, len(disclosures))
+	for claim, d := range disclosures {
+		details.Disclosures[claim] = d
+	}
+	*student = details
+	chain.addStudentNumber(student)
+	return nil
+}
+
+// AddCredential adds a new credential to the student's list of non-academic credentials.
+// The credential is issued by signer, an admin who signs for the issuer, since the chain only accepts signed credentials.
+func (s *Student) AddCredential(credentialType CredentialType, issuer string, dataIssued time.Time, signer *Admin) bool {
 	// Check if the credential type is valid
 	if credentialType != NonAcademic && credentialType != Academic {
 		return false //fmt.Errorf("invalid credential type")
@@ -31,6 +251,10 @@ func (s *Student) AddCredential(credentialType CredentialType, issuer string, da
 		return false //fmt.Errorf("only non-academic credentials can be added")
 	}
 
+	if signer == nil {
+		return false //fmt.Errorf("credential needs an issuing admin")
+	}
+
 	// Create a new credential
 	newCredential := Credential{
 		Type:       credentialType,
@@ -38,17 +262,8 @@ func (s *Student) AddCredential(credentialType CredentialType, issuer string, da
 		DateIssued: dataIssued,
 	}
 
-	// Validate the credential data
-	if err := ValidateCredentialData(&newCredential); err != nil {
-		return false
-	}
-
-	// Generate and store the credential hash
-	newCredential.Hash = GenerateCredentialHash(&newCredential)
-
-	// Add the credential to the student's list of credentials
-	s.Credentials = append(s.Credentials, &newCredential)
-	return true
+	// The issuing admin assigns its ID, validates it, signs it and adds it to the student's credentials
+	return signer.IssueCredential(s, &newCredential) == nil
 }
 
 // UpdateStudentCredentials updates the credentials of the student
@@ -72,10 +287,22 @@ func (chain *StudentChain) UpdateStudentCredentials(id int, newCredential Creden
 
 }
 
-// FindStudentByID should find and return a student by ID (this needs to be implemented)
+// FindStudentByID finds and returns a student by internal ID.
 func (chain *StudentChain) FindStudentByID(id int) (*Student, error) {
+	if student, ok := chain.Students[id]; ok {
+		return student, nil
+	}
+	return nil, fmt.Errorf("Student not found")
+}
+
+// FindStudentByNumber finds and returns a student by student number.
+func (chain *StudentChain) FindStudentByNumber(studentNum int) (*Student, error) {
+	if student, ok := chain.byNumber[studentNum]; ok {
+		return student, nil
+	}
+	// Students added to the map directly are not in the student number lookup
 	for _, student := range chain.Students {
-		if student.StudentID == id {
+		if student.StudentID == studentNum {
 			return student, nil
 		}
 	}
@@ -0,0 +1,201 @@
+package model
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestStudentAddCredentialIsSigned(t *testing.T) {
+	l := newTestLedger(t)
+	student := testStudent(1)
+	if !student.AddCredential(NonAcademic, testIssuerName, time.Now().Add(-time.Hour), l.signer()) {
+		t.Fatal("AddCredential failed")
+	}
+	cred := student.Credentials[0]
+	if cred.OwnerID != student.ID || cred.ID == "" || len(cred.Signature) == 0 {
+		t.Fatalf("credential %+v is not an issued credential of student %d", cred, student.ID)
+	}
+	if err := l.chain.AddCredentialModel(cred); err != nil {
+		t.Fatalf("chain rejected the student's credential: %v", err)
+	}
+	expectStatus(t, l.chain.VerifyCredential(cred.ID), StatusValid)
+}
+
+func TestStudentAddCredentialRefusals(t *testing.T) {
+	l := newTestLedger(t)
+	student := testStudent(1)
+	issued := time.Now().Add(-time.Hour)
+	if student.AddCredential(Academic, testIssuerName, issued, l.signer()) {
+		t.Error("a student added an academic credential")
+	}
+	if student.AddCredential(NonAcademic, testIssuerName, issued, nil) {
+		t.Error("a credential was added without an issuing admin")
+	}
+	if student.AddCredential(NonAcademic, testIssuerName, time.Now().Add(time.Hour), l.signer()) {
+		t.Error("a credential issued in the future was added")
+	}
+	if len(student.Credentials) != 0 {
+		t.Fatalf("student holds %d credentials after refused additions", len(student.Credentials))
+	}
+}
+
+// registrationEntry encodes a registration committing to the student's details as a ledger entry,
+// and returns the disclosures of the details.
+func registrationEntry(t *testing.T, s *Student) ([]byte, map[string]Disclosure) {
+	t.Helper()
+	commitments, disclosures, err := commitClaims(StudentClaims(s))
+	if err != nil {
+		t.Fatal(err)
+	}
+	registration := &StudentRegistration{ID: s.ID, Commitments: commitments, RegisteredBy: "registrar", RegisteredAt: time.Now().UTC()}
+	data, err := EncodePayload(PayloadStudentRegistered, registration)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data, disclosures
+}
+
+func TestAddNewStudentChecksTheLedger(t *testing.T) {
+	l := newTestLedger(t)
+	students, err := NewStudentChain(l.chain)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Another writer registers student 5 after the registry was built
+	other := testStudent(5)
+	entry, disclosures := registrationEntry(t, other)
+	if err := l.chain.AddBlockEntries([][]byte{entry}, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	height := len(l.chain.Blocks)
+
+	if _, err := l.signer().AddNewStudent(5, "Grace", "Hopper", other.BirthDate, 202499999, students); err == nil {
+		t.Error("a student ID already on the ledger was registered again")
+	}
+	// The ledger only holds a commitment to the number; once the registry knows it, it is refused too
+	if err := students.RestoreStudentDetails(5, disclosures); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := l.signer().AddNewStudent(6, "Grace", "Hopper", other.BirthDate, other.StudentID, students); err == nil {
+		t.Error("a registered student number was registered again")
+	}
+	if len(l.chain.Blocks) != height {
+		t.Fatalf("refused registrations wrote %d blocks", len(l.chain.Blocks)-height)
+	}
+
+	if _, err := NewStudentChain(l.chain); err != nil {
+		t.Fatalf("ledger no longer replays: %v", err)
+	}
+	if found, err := students.FindStudentByNumber(other.StudentID); err != nil || found.ID != 5 {
+		t.Fatalf("student number %d found %+v, %v; want student 5", other.StudentID, found, err)
+	}
+}
+
+func TestStudentRegistryReplay(t *testing.T) {
+	l := newTestLedger(t)
+	students, err := NewStudentChain(l.chain)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := testStudent(1)
+	registered, err := l.signer().AddNewStudent(s.ID, s.FirstName, s.LastName, s.BirthDate, s.StudentID, students)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if registered.FirstName != s.FirstName || registered.StudentID != s.StudentID || !registered.BirthDate.Equal(s.BirthDate) {
+		t.Fatalf("registered student is %+v, want the details given", registered)
+	}
+	cred := l.add(t, registered, nil)
+
+	// A block written without the ledger's checks registers student 1 again under another number
+	duplicate := testStudent(1)
+	duplicate.FirstName, duplicate.StudentID = "Impostor", 202488888
+	entry, forged := registrationEntry(t, duplicate)
+	if err := l.chain.addBlockEntries([][]byte{entry}); err != nil {
+		t.Fatal(err)
+	}
+
+	replayed, err := NewStudentChain(l.chain)
+	if err != nil {
+		t.Fatalf("replay: %v", err)
+	}
+	found, err := replayed.FindStudentByID(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(found.Credentials) != 1 || found.Credentials[0].ID != cred.ID {
+		t.Errorf("replayed student holds %d credentials, want the one issued", len(found.Credentials))
+	}
+	// The ledger holds no details, so they are restored from the first registration's disclosures
+	if found.FirstName != "" || found.StudentID != 0 {
+		t.Fatalf("replayed student %+v has details the ledger should not hold", found)
+	}
+	if err := replayed.RestoreStudentDetails(1, forged); err == nil {
+		t.Fatal("details of the duplicate registration were restored")
+	}
+	if err := replayed.RestoreStudentDetails(1, registered.Disclosures); err != nil {
+		t.Fatal(err)
+	}
+	if found.FirstName != s.FirstName || found.StudentID != s.StudentID {
+		t.Errorf("restored student 1 is %+v, want the first registration", found)
+	}
+	if _, err := replayed.FindStudentByNumber(duplicate.StudentID); err == nil {
+		t.Error("the duplicate registration's student number was registered")
+	}
+	if byNumber, err := replayed.FindStudentByNumber(s.StudentID); err != nil || byNumber != found {
+		t.Errorf("student number %d found %+v, %v; want student 1", s.StudentID, byNumber, err)
+	}
+}
+
+func TestStudentRegistrationKeepsDetailsOffTheLedger(t *testing.T) {
+	l := newTestLedger(t)
+	students, err := NewStudentChain(l.chain)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := testStudent(1)
+	if _, err := l.signer().AddNewStudent(s.ID, s.FirstName, s.LastName, s.BirthDate, s.StudentID, students); err != nil {
+		t.Fatal(err)
+	}
+	for _, block := range l.chain.Blocks {
+		for _, payload := range block.Payloads() {
+			for _, detail := range []string{s.LastName, "2001-12-10", "202400001"} {
+				if bytes.Contains(payload, []byte(detail)) {
+					t.Fatalf("block %d records %q in clear", block.Index, detail)
+				}
+			}
+		}
+	}
+
+	// Registrations recording details in clear are refused, but old ones still replay
+	legacy := testStudent(2)
+	entry, err := json.Marshal(Envelope{Type: PayloadStudentRegistered, Version: 1, Payload: mustJSON(t, map[string]interface{}{"student": legacy, "registered_by": "registrar"})})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := l.chain.AddBlockEntries([][]byte{entry}, time.Now()); err == nil {
+		t.Fatal("a registration recording the student's details in clear was added")
+	}
+	if err := l.chain.addBlockEntries([][]byte{entry}); err != nil {
+		t.Fatal(err)
+	}
+	replayed, err := NewStudentChain(l.chain)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if found, err := replayed.FindStudentByNumber(legacy.StudentID); err != nil || found.ID != legacy.ID || found.LastName != legacy.LastName {
+		t.Fatalf("version 1 registration replayed as %+v, %v", found, err)
+	}
+}
+
+// mustJSON encodes value as JSON.
+func mustJSON(t *testing.T, value interface{}) []byte {
+	t.Helper()
+	data, err := json.Marshal(value)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
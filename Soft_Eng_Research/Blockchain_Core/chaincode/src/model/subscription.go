@@ -0,0 +1,194 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// ErrSubscriptionClosed is returned by a subscription's Err after it was closed with Close.
+var ErrSubscriptionClosed = errors.New("subscription closed")
+
+// BlockEvent is a block appended to the ledger together with the decoded events it records.
+type BlockEvent struct {
+	Block  Block
+	Events []LedgerEvent
+}
+
+// LedgerEvent is one decoded entry of a block. Value holds the decoded payload, such as a *Credential
+// for PayloadCredentialIssued or a *Revocation for PayloadCredentialRevoked. Entries that cannot be
+// decoded are still delivered, with Err saying why.
+type LedgerEvent struct {
+	Entry   int
+	Type    PayloadType
+	Version int
+	Value   interface{}
+	Err     error
+}
+
+// newBlockEvent decodes every entry of the block.
+func newBlockEvent(block Block) BlockEvent {
+	payloads := block.Payloads()
+	event := BlockEvent{Block: block, Events: make([]LedgerEvent, 0, len(payloads))}
+	for i, payload := range payloads {
+		decoded := LedgerEvent{Entry: i}
+		envelope, value, err := DecodePayload(payload)
+		if envelope != nil {
+			decoded.Type = envelope.Type
+			decoded.Version = envelope.Version
+		}
+		decoded.Value = value
+		decoded.Err = err
+		event.Events = append(event.Events, decoded)
+	}
+	return event
+}
+
+// Subscription delivers the blocks appended through a LedgerService in chain order, starting from a given height.
+// Each subscription reads the chain on its own goroutine, so a slow subscriber never holds up writers.
+type Subscription struct {
+	service *LedgerService
+	next    int
+	deliver func(BlockEvent) error
+
+	// C receives the block events of a subscription created with Subscribe, and is closed when it ends
+	C <-chan BlockEvent
+
+	closeOnce sync.Once
+	closed    chan struct{}
+	done      chan struct{}
+	err       error
+}
+
+// Subscribe returns a subscription that sends every block from index from onwards, including blocks already
+// on the chain, on its channel C. A consumer resumes after a restart by subscribing from one past the last
+// block it handled. buffer is the number of events that may wait in C unread.
+func (s *LedgerService) Subscribe(from, buffer int) (*Subscription, error) {
+	if buffer < 0 {
+		return nil, fmt.Errorf("subscription buffer must not be negative")
+	}
+	events := make(chan BlockEvent, buffer)
+	sub := s.newSubscription(from)
+	sub.C = events
+	sub.deliver = func(event BlockEvent) error {
+		select {
+		case events <- event:
+			return nil
+		case <-sub.closed:
+			return ErrSubscriptionClosed
+		}
+	}
+	if err := sub.start(func() { close(events) }); err != nil {
+		return nil, err
+	}
+	return sub, nil
+}
+
+// SubscribeFunc returns a subscription that calls fn with every block from index from onwards, one at a time
+// and in chain order. If fn returns an error the subscription ends with that error.
+func (s *LedgerService) SubscribeFunc(from int, fn func(BlockEvent) error) (*Subscription, error) {
+	if fn == nil {
+		return nil, fmt.Errorf("subscription needs a callback")
+	}
+	sub := s.newSubscription(from)
+	sub.deliver = fn
+	if err := sub.start(nil); err != nil {
+		return nil, err
+	}
+	return sub, nil
+}
+
+func (s *LedgerService) newSubscription(from int) *Subscription {
+	return &Subscription{
+		service: s,
+		next:    from,
+		closed:  make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+}
+
+// start checks that the subscription can be served from the chain and starts delivering to it.
+func (sub *Subscription) start(stopped func()) error {
+	s := sub.service
+	s.mu.RLock()
+	base := s.chain.snapshot.height()
+	s.mu.RUnlock()
+	if sub.next < 0 {
+		return fmt.Errorf("cannot subscribe from block %d", sub.next)
+	}
+	if sub.next < base {
+		return fmt.Errorf("cannot subscribe from block %d of a chain bootstrapped from a snapshot at height %d", sub.next, base)
+	}
+
+	go func() {
+		sub.err = sub.run()
+		if stopped != nil {
+			stopped()
+		}
+		close(sub.done)
+	}()
+	return nil
+}
+
+// run delivers the blocks the subscription has not seen yet, then waits for more, until it is closed
+// or a delivery fails.
+func (sub *Subscription) run() error {
+	for {
+		// Copy the pending blocks out under the read lock, and deliver them after releasing it
+		s := sub.service
+		s.mu.RLock()
+		var pending []Block
+		for index := sub.next; index < s.chain.Height(); index++ {
+			pending = append(pending, s.chain.block(index).clone())
+		}
+		appended := s.appended
+		s.mu.RUnlock()
+
+		for _, block := range pending {
+			select {
+			case <-sub.closed:
+				return ErrSubscriptionClosed
+			default:
+			}
+			if err := sub.deliver(newBlockEvent(block)); err != nil {
+				return err
+			}
+			sub.next = block.Index + 1
+		}
+
+		select {
+		case <-appended:
+		case <-sub.closed:
+			return ErrSubscriptionClosed
+		}
+	}
+}
+
+// Close stops the subscription. Blocks appended afterwards are not delivered, and the channel of a
+// subscription created with Subscribe is closed once its pending send is abandoned.
+func (sub *Subscription) Close() {
+	sub.closeOnce.Do(func() { close(sub.closed) })
+}
+
+// Done returns a channel that is closed once the subscription has stopped delivering blocks.
+func (sub *Subscription) Done() <-chan struct{} {
+	return sub.done
+}
+
+// Err returns why the subscription stopped: ErrSubscriptionClosed after Close, or the error returned by
+// its callback. It returns nil while the subscription is still running.
+func (sub *Subscription) Err() error {
+	select {
+	case <-sub.done:
+		return sub.err
+	default:
+		return nil
+	}
+}
+
+// Next waits for the subscription to stop and returns the index of the first block it did not deliver,
+// which is where a consumer resumes from with a new subscription.
+func (sub *Subscription) Next() int {
+	<-sub.done
+	return sub.next
+}
@@ -0,0 +1,187 @@
+package model
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// receive waits for the next block event of the subscription.
+func receive(t *testing.T, sub *Subscription) BlockEvent {
+	t.Helper()
+	select {
+	case event, ok := <-sub.C:
+		if !ok {
+			t.Fatalf("subscription ended: %v", sub.Err())
+		}
+		return event
+	case <-time.After(5 * time.Second):
+		t.Fatal("no block delivered")
+	}
+	return BlockEvent{}
+}
+
+// waitDone waits for the subscription to stop.
+func waitDone(t *testing.T, sub *Subscription) {
+	t.Helper()
+	select {
+	case <-sub.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("subscription did not stop")
+	}
+}
+
+func TestSubscribeDeliversBlocksInOrder(t *testing.T) {
+	l := newTestLedger(t)
+	service := newTestService(t, l)
+	existing := l.chain.Height()
+
+	sub, err := service.Subscribe(0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sub.Close()
+	for i := 0; i < existing; i++ {
+		if event := receive(t, sub); event.Block.Index != i {
+			t.Fatalf("received block %d, want %d", event.Block.Index, i)
+		}
+	}
+
+	cred := l.issue(t, testStudent(1), nil)
+	if err := service.AddCredential(cred); err != nil {
+		t.Fatal(err)
+	}
+	event := receive(t, sub)
+	if event.Block.Index != existing || len(event.Events) != 1 {
+		t.Fatalf("received block %d with %d events", event.Block.Index, len(event.Events))
+	}
+	decoded := event.Events[0]
+	issued, ok := decoded.Value.(*Credential)
+	if decoded.Err != nil || decoded.Type != PayloadCredentialIssued || !ok || issued.ID != cred.ID {
+		t.Fatalf("event is %+v", decoded)
+	}
+}
+
+func TestSubscriptionResumes(t *testing.T) {
+	l := newTestLedger(t)
+	service := newTestService(t, l)
+	if err := service.AddCredential(l.issue(t, testStudent(1), nil)); err != nil {
+		t.Fatal(err)
+	}
+
+	var seen []int
+	stop := errors.New("stop")
+	sub, err := service.SubscribeFunc(0, func(event BlockEvent) error {
+		seen = append(seen, event.Block.Index)
+		if len(seen) == 2 {
+			return stop
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	waitDone(t, sub)
+	if !errors.Is(sub.Err(), stop) {
+		t.Fatalf("subscription ended with %v, want the callback's error", sub.Err())
+	}
+	// The block whose delivery failed was not handled, so it is delivered again on resuming
+	next := sub.Next()
+	if next != 1 {
+		t.Fatalf("subscription resumes from %d, want 1", next)
+	}
+
+	resumed, err := service.Subscribe(next, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resumed.Close()
+	if event := receive(t, resumed); event.Block.Index != 1 {
+		t.Fatalf("resumed at block %d, want 1", event.Block.Index)
+	}
+}
+
+func TestSubscriptionClose(t *testing.T) {
+	l := newTestLedger(t)
+	service := newTestService(t, l)
+
+	// Nothing reads the unbuffered channel, so the subscription is blocked sending the genesis block
+	sub, err := service.Subscribe(0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sub.Close()
+	sub.Close()
+	waitDone(t, sub)
+	if !errors.Is(sub.Err(), ErrSubscriptionClosed) {
+		t.Fatalf("closed subscription ended with %v", sub.Err())
+	}
+	if _, ok := <-sub.C; ok {
+		t.Fatal("closed subscription still delivers blocks")
+	}
+	if sub.Next() != 0 {
+		t.Fatalf("closed subscription resumes from %d, want 0", sub.Next())
+	}
+}
+
+func TestSubscriptionDeliversCopies(t *testing.T) {
+	l := newTestLedger(t)
+	service := newTestService(t, l)
+	if err := service.AddCredential(l.issue(t, testStudent(1), nil)); err != nil {
+		t.Fatal(err)
+	}
+	last := l.chain.Height() - 1
+	delivered := make(chan struct{})
+	sub, err := service.SubscribeFunc(0, func(event BlockEvent) error {
+		for _, entry := range event.Block.Entries {
+			spoil(entry)
+		}
+		spoil(event.Block.Hash)
+		if event.Block.Index == last {
+			close(delivered)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-delivered:
+	case <-time.After(5 * time.Second):
+		t.Fatal("no block delivered")
+	}
+	sub.Close()
+	waitDone(t, sub)
+
+	err = service.View(func(view *LedgerView) error {
+		return view.Validate().Err()
+	})
+	if err != nil {
+		t.Fatalf("changing delivered blocks broke the chain: %v", err)
+	}
+}
+
+func TestSubscribeRefusals(t *testing.T) {
+	l := newTestLedger(t)
+	l.add(t, testStudent(1), nil)
+	_, chain := l.bootstrap(t, l.chain.Height())
+	service, err := NewLedgerService(chain)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := service.Subscribe(0, 0); err == nil {
+		t.Error("subscribed from a block covered by the chain's snapshot")
+	}
+	if _, err := service.Subscribe(chain.Height(), -1); err == nil {
+		t.Error("subscribed with a negative buffer")
+	}
+	if _, err := service.SubscribeFunc(chain.Height(), nil); err == nil {
+		t.Error("subscribed without a callback")
+	}
+	sub, err := service.Subscribe(chain.Height(), 0)
+	if err != nil {
+		t.Fatalf("subscribing from the snapshot's height: %v", err)
+	}
+	sub.Close()
+}
@@ -0,0 +1,75 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrCredentialSuperseded is returned when verifying a credential that has been replaced by a newer version.
+var ErrCredentialSuperseded = errors.New("credential has been superseded")
+
+// SupersedeCredential records replacement as the new version of the credential with the given ID,
+// for example to correct a misspelled name or a wrong date. The replacement must be for the same owner
+// and issuer, and the previous credential must be neither revoked nor already superseded.
+// The replacement must already name the previous credential in Supersedes, since it is covered by the signature.
+func (chain *CredentialChain) SupersedeCredential(previousID string, replacement *Credential) error {
+	if replacement.Supersedes == "" {
+		return fmt.Errorf("credential does not name the credential it supersedes")
+	}
+	if replacement.Supersedes != previousID {
+		return fmt.Errorf("credential supersedes %s, not %s", replacement.Supersedes, previousID)
+	}
+	return chain.AddCredentialModel(replacement)
+}
+
+// checkSupersedes checks that cred may replace the credential it names in Supersedes.
+func (chain *CredentialChain) checkSupersedes(cred *Credential) error {
+	previous, err := chain.FindCredentialByID(cred.Supersedes)
+	if err != nil {
+		return err
+	}
+	if previous.OwnerID != cred.OwnerID {
+		return fmt.Errorf("credential %s belongs to another student", previous.ID)
+	}
+	if previous.Issuer != cred.Issuer {
+		return fmt.Errorf("credential %s was issued by %s, not %s", previous.ID, previous.Issuer, cred.Issuer)
+	}
+	if successor, ok := chain.indexes().supersededBy[previous.ID]; ok {
+		return fmt.Errorf("credential %s is already superseded by %s", previous.ID, successor)
+	}
+	if revocation, _ := chain.FindRevocation(previous.ID); revocation != nil {
+		return &CredentialRevokedError{Revocation: revocation}
+	}
+	return nil
+}
+
+// SupersededBy returns the ID of the credential that replaced the credential with the given ID,
+// or an empty string if it is the latest version.
+func (chain *CredentialChain) SupersededBy(id string) string {
+	return chain.indexes().supersededBy[id]
+}
+
+// CredentialHistory returns every version of the credential with the given ID, oldest first.
+// Any version in the lineage can be given.
+func (chain *CredentialChain) CredentialHistory(id string) ([]*Credential, error) {
+	idx := chain.indexes()
+	if _, ok := idx.byID[id]; !ok {
+		return nil, fmt.Errorf("credential with ID %s not found", id)
+	}
+
+	// Walk back to the first version, then forward through its successors
+	first := id
+	for {
+		previous := idx.byID[first].Credential.Supersedes
+		if previous == "" || idx.supersededBy[previous] != first {
+			break
+		}
+		first = previous
+	}
+
+	var lineage []string
+	for current := first; current != ""; current = idx.supersededBy[current] {
+		lineage = append(lineage, current)
+	}
+	return idx.credentials(lineage), nil
+}
@@ -0,0 +1,110 @@
+package model
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// reissue returns a new version of the student's credential, without adding it to the chain.
+func (l *testLedger) reissue(t *testing.T, s *Student, previousID string) *Credential {
+	t.Helper()
+	return l.issue(t, s, func(cred *Credential) { cred.Supersedes = previousID })
+}
+
+func TestSupersedeCredential(t *testing.T) {
+	l := newTestLedger(t)
+	s := testStudent(1)
+	first := l.add(t, s, nil)
+	second := l.reissue(t, s, first.ID)
+	if err := l.chain.SupersedeCredential(first.ID, second); err != nil {
+		t.Fatal(err)
+	}
+	third := l.reissue(t, s, second.ID)
+	if err := l.chain.SupersedeCredential(second.ID, third); err != nil {
+		t.Fatal(err)
+	}
+
+	report := l.chain.VerifyCredential(first.ID)
+	expectStatus(t, report, StatusSuperseded)
+	if report.SupersededBy != second.ID {
+		t.Errorf("first version superseded by %s, want %s", report.SupersededBy, second.ID)
+	}
+	if err := report.Err(); !errors.Is(err, ErrCredentialSuperseded) {
+		t.Errorf("Err returned %v, want %v", err, ErrCredentialSuperseded)
+	}
+	expectStatus(t, l.chain.VerifyCredential(third.ID), StatusValid)
+
+	for _, id := range []string{first.ID, second.ID, third.ID} {
+		history, err := l.chain.CredentialHistory(id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(history) != 3 || history[0].ID != first.ID || history[1].ID != second.ID || history[2].ID != third.ID {
+			t.Fatalf("history from %s is %d versions", id, len(history))
+		}
+	}
+}
+
+func TestSupersedeCredentialRefusals(t *testing.T) {
+	l := newTestLedger(t)
+	s := testStudent(1)
+	previous := l.add(t, s, nil)
+
+	if err := l.chain.SupersedeCredential(previous.ID, l.issue(t, s, nil)); err == nil {
+		t.Error("a credential that does not name the one it supersedes was added")
+	}
+	if err := l.chain.SupersedeCredential(previous.ID, l.reissue(t, s, "other")); err == nil {
+		t.Error("a credential superseding another credential was added")
+	}
+	if err := l.chain.SupersedeCredential(previous.ID, l.reissue(t, testStudent(2), previous.ID)); err == nil {
+		t.Error("another student's credential replaced the student's credential")
+	}
+	signer := l.addIssuer(t, "Other College", time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC), nil)
+	other := signedCredential(t, signer, "Other College", time.Now().Add(-time.Hour))
+	other.Supersedes = previous.ID
+	if err := signer.SignCredential(other); err != nil {
+		t.Fatal(err)
+	}
+	if err := l.chain.SupersedeCredential(previous.ID, other); err == nil {
+		t.Error("another issuer's credential replaced the credential")
+	}
+	if l.chain.SupersededBy(previous.ID) != "" {
+		t.Fatal("a refused replacement superseded the credential")
+	}
+
+	if err := l.chain.SupersedeCredential(previous.ID, l.reissue(t, s, previous.ID)); err != nil {
+		t.Fatal(err)
+	}
+	if err := l.chain.SupersedeCredential(previous.ID, l.reissue(t, s, previous.ID)); err == nil {
+		t.Error("a credential was superseded twice")
+	}
+}
+
+func TestSupersedeRevokedCredential(t *testing.T) {
+	l := newTestLedger(t)
+	s := testStudent(1)
+	previous := l.add(t, s, nil)
+	if err := l.chain.RevokeCredential(previous.ID, "issued in error", l.signer()); err != nil {
+		t.Fatal(err)
+	}
+	var revoked *CredentialRevokedError
+	if err := l.chain.SupersedeCredential(previous.ID, l.reissue(t, s, previous.ID)); !errors.As(err, &revoked) {
+		t.Fatalf("superseding a revoked credential returned %v", err)
+	}
+}
+
+func TestUncheckedReplacementDoesNotSupersede(t *testing.T) {
+	l := newTestLedger(t)
+	previous := l.add(t, testStudent(1), nil)
+
+	// A replacement for another owner, written without admission, must not take the credential over
+	forged := l.reissue(t, testStudent(2), previous.ID)
+	if err := l.chain.addBlockEntries([][]byte{credentialEntry(t, forged)}); err != nil {
+		t.Fatal(err)
+	}
+	if by := l.chain.SupersededBy(previous.ID); by != "" {
+		t.Fatalf("credential superseded by %s, which belongs to another student", by)
+	}
+	expectStatus(t, l.chain.VerifyCredential(previous.ID), StatusValid)
+}
@@ -1,19 +1,174 @@
 package model
 
 import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
 	"crypto/sha256"
+	"encoding/binary"
 	"fmt"
+	"sort"
+	"strconv"
 	"time"
 )
 
-// Serialize converts the Credential to a custom byte array format
+const (
+	// LegacyCredentialHashVersion hashes the credential's Serialize format.
+	LegacyCredentialHashVersion = 0
+	// CredentialHashVersion hashes the credential's SerializeCanonical format. New credentials are hashed with it.
+	CredentialHashVersion = 1
+)
+
+// credentialIDAlphabet is Crockford's base32 alphabet, whose characters sort in the same order as their values.
+const credentialIDAlphabet = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// NewCredentialID generates a unique, sortable credential ID.
+// The ID is 26 base32 characters encoding a 48-bit millisecond timestamp followed by 80 random bits,
+// so IDs sort by issuance time and two IDs issued in the same millisecond are still distinct.
+func NewCredentialID() (string, error) {
+	var raw [16]byte
+	ms := uint64(time.Now().UnixMilli())
+	for i := 0; i < 6; i++ {
+		raw[i] = byte(ms >> (40 - 8*i))
+	}
+	if _, err := rand.Read(raw[6:]); err != nil {
+		return "", fmt.Errorf("failed to generate credential ID: %w", err)
+	}
+
+	// Encode the 128 bits five at a time, padding the front with two zero bits
+	id := make([]byte, 26)
+	var acc uint64
+	bits := 2
+	pos := 0
+	for _, b := range raw {
+		acc = acc<<8 | uint64(b)
+		bits += 8
+		for bits >= 5 {
+			bits -= 5
+			id[pos] = credentialIDAlphabet[(acc>>bits)&0x1f]
+			pos++
+		}
+	}
+	return string(id), nil
+}
+
+// Serialize converts the Credential to the legacy byte format hashed by credentials with HashVersion 0.
+// The fields are not escaped and the owner and status are left out, so new credentials use SerializeCanonical instead.
+// The validity window, document digest, claim commitments and superseded ID are only appended when set, so credentials without them keep their original hash.
 func (cred *Credential) Serialize() []byte {
-	return []byte(fmt.Sprintf("%d|%s|%s|%s", cred.Type, cred.Issuer, cred.ID, cred.DateIssued.Format(time.RFC3339)))
+	data := fmt.Sprintf("%d|%s|%s|%s", cred.Type, cred.Issuer, cred.ID, cred.DateIssued.Format(time.RFC3339))
+	if cred.ValidFrom != nil || cred.ExpiresAt != nil {
+		data += fmt.Sprintf("|%s|%s", formatOptionalTime(cred.ValidFrom), formatOptionalTime(cred.ExpiresAt))
+	}
+	if cred.Document != nil {
+		data += "|doc=" + cred.Document.String()
+	}
+	if len(cred.Commitments) > 0 {
+		data += "|commit=" + serializeCommitments(cred.Commitments)
+	}
+	if cred.Supersedes != "" {
+		data += "|supersedes=" + cred.Supersedes
+	}
+	return []byte(data)
+}
+
+// cloneTime returns a copy of the optional time t.
+func cloneTime(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	copied := *t
+	return &copied
+}
+
+func formatOptionalTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
+
+// SerializeCanonical converts the Credential to the canonical byte format hashed by credentials with HashVersion 1.
+// It starts with the version byte, followed by every field as a 4-byte big-endian length and its bytes,
+// so no value can run into the next one. Optional fields that are not set are encoded as empty.
+func (cred *Credential) SerializeCanonical() []byte {
+	var buf bytes.Buffer
+	buf.WriteByte(CredentialHashVersion)
+
+	field := func(value string) {
+		writeCanonicalField(&buf, value)
+	}
+	optionalTime := func(t *time.Time) string {
+		if t == nil {
+			return ""
+		}
+		return t.UTC().Format(time.RFC3339Nano)
+	}
+
+	field(cred.ID)
+	field(strconv.Itoa(cred.OwnerID))
+	field(strconv.Itoa(int(cred.Type)))
+	field(cred.Issuer)
+	field(cred.DateIssued.UTC().Format(time.RFC3339Nano))
+	field(optionalTime(cred.ValidFrom))
+	field(optionalTime(cred.ExpiresAt))
+	if cred.Document != nil {
+		field(cred.Document.String())
+	} else {
+		field("")
+	}
+
+	// Commitments are written as a count followed by each claim and commitment in claim order
+	claims := make([]string, 0, len(cred.Commitments))
+	for claim := range cred.Commitments {
+		claims = append(claims, claim)
+	}
+	sort.Strings(claims)
+	field(strconv.Itoa(len(claims)))
+	for _, claim := range claims {
+		field(claim)
+		field(string(cred.Commitments[claim]))
+	}
+
+	field(cred.Supersedes)
+	field(cred.Status)
+	field(cred.SignerID)
+	return buf.Bytes()
+}
+
+// writeCanonicalField writes value to buf as a 4-byte big-endian length followed by its bytes.
+func writeCanonicalField(buf *bytes.Buffer, value string) {
+	var length [4]byte
+	binary.BigEndian.PutUint32(length[:], uint32(len(value)))
+	buf.Write(length[:])
+	buf.WriteString(value)
 }
 
 // GenerateCredentialHash creates a hash of the credential data for integrity
+// The data is serialized in the format of the credential's HashVersion, so credentials hashed under the legacy format still verify.
+// It returns nil for an unknown hash version.
 func GenerateCredentialHash(cred *Credential) []byte {
-	credData := cred.Serialize()
+	var credData []byte
+	switch cred.HashVersion {
+	case LegacyCredentialHashVersion:
+		credData = cred.Serialize()
+	case CredentialHashVersion:
+		credData = cred.SerializeCanonical()
+	default:
+		return nil
+	}
 	hash := sha256.Sum256(credData)
 	return hash[:]
 }
+
+// VerifyCredentialSignature checks that the credential's signature over its hash was made with key.
+func VerifyCredentialSignature(cred *Credential, key ed25519.PublicKey) bool {
+	if len(key) != ed25519.PublicKeySize {
+		return false
+	}
+	hash := GenerateCredentialHash(cred)
+	if hash == nil || !bytes.Equal(cred.Hash, hash) {
+		return false
+	}
+	return ed25519.Verify(key, hash, cred.Signature)
+}
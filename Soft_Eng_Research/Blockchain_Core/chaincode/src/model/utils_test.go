@@ -0,0 +1,81 @@
+package model
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"testing"
+	"time"
+)
+
+func TestCanonicalSerializationIsUnambiguous(t *testing.T) {
+	issued := time.Date(2024, 6, 1, 9, 0, 0, 0, time.UTC)
+	first := &Credential{ID: "c", Type: Certificate, Issuer: "a|b", DateIssued: issued}
+	second := &Credential{ID: "b|c", Type: Certificate, Issuer: "a", DateIssued: issued}
+
+	// The legacy format joins the fields with "|", so these two collide
+	if !bytes.Equal(first.Serialize(), second.Serialize()) {
+		t.Fatal("legacy serializations differ; the test no longer shows the ambiguity")
+	}
+	if bytes.Equal(first.SerializeCanonical(), second.SerializeCanonical()) {
+		t.Fatal("different credentials share a canonical serialization")
+	}
+	if first.SerializeCanonical()[0] != CredentialHashVersion {
+		t.Fatalf("canonical serialization starts with %d, want the version byte %d", first.SerializeCanonical()[0], CredentialHashVersion)
+	}
+}
+
+func TestCanonicalHashCoversOwnerStatusAndSigner(t *testing.T) {
+	base := Credential{ID: "c", OwnerID: 1, Type: Certificate, Issuer: "a", DateIssued: time.Now(), HashVersion: CredentialHashVersion}
+	hash := GenerateCredentialHash(&base)
+	tests := map[string]func(cred *Credential){
+		"owner":  func(cred *Credential) { cred.OwnerID = 2 },
+		"status": func(cred *Credential) { cred.Status = "draft" },
+		"signer": func(cred *Credential) { cred.SignerID = "someone" },
+	}
+	for name, change := range tests {
+		t.Run(name, func(t *testing.T) {
+			cred := base
+			change(&cred)
+			if bytes.Equal(GenerateCredentialHash(&cred), hash) {
+				t.Fatalf("changing the %s does not change the hash", name)
+			}
+		})
+	}
+}
+
+func TestLegacyHashStillVerifies(t *testing.T) {
+	publicKey, privateKey, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cred := &Credential{ID: "legacy", Type: Certificate, Issuer: "a", DateIssued: time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)}
+	cred.Hash = GenerateCredentialHash(cred)
+	legacy := sha256.Sum256(cred.Serialize())
+	if !bytes.Equal(cred.Hash, legacy[:]) {
+		t.Fatal("a version 0 credential is not hashed in the legacy format")
+	}
+	cred.Signature = ed25519.Sign(privateKey, cred.Hash)
+	if !VerifyCredentialSignature(cred, publicKey) {
+		t.Fatal("a credential hashed in the legacy format does not verify")
+	}
+	cred.Issuer = "b"
+	if VerifyCredentialSignature(cred, publicKey) {
+		t.Fatal("a changed legacy credential verifies")
+	}
+}
+
+func TestRevocationKeepsSignatureValid(t *testing.T) {
+	l := newTestLedger(t)
+	cred := l.add(t, testStudent(1), nil)
+	if err := l.chain.RevokeCredential(cred.ID, "issued in error", l.signer()); err != nil {
+		t.Fatal(err)
+	}
+	report := l.chain.VerifyCredential(cred.ID)
+	expectStatus(t, report, StatusRevoked)
+	for _, check := range report.Checks {
+		if check.Check != CheckRevocation && !check.Passed {
+			t.Errorf("revoked credential failed the %s check: %s", check.Check, check.Reason)
+		}
+	}
+}
@@ -0,0 +1,142 @@
+package model
+
+import (
+	"bytes"
+	"fmt"
+	"time"
+)
+
+// ValidationFailure names the kind of breakage found while validating a chain.
+type ValidationFailure string
+
+const (
+	FailureNone               ValidationFailure = ""
+	FailureEmptyChain         ValidationFailure = "empty-chain"
+	FailureInvalidGenesis     ValidationFailure = "invalid-genesis"
+	FailureIndexGap           ValidationFailure = "index-discontinuity"
+	FailureHashMismatch       ValidationFailure = "hash-mismatch"
+	FailureMerkleRoot         ValidationFailure = "merkle-root-mismatch"
+	FailureBrokenLink         ValidationFailure = "broken-link"
+	FailureInvalidTimestamp   ValidationFailure = "invalid-timestamp"
+	FailureTimestampRegressed ValidationFailure = "timestamp-regression"
+	FailureUnknownVersion     ValidationFailure = "unknown-version"
+)
+
+// ValidationReport is the result of auditing a chain.
+// When the chain is broken, BrokenBlock is the position of the first bad block and Failure says what is wrong with it.
+type ValidationReport struct {
+	Valid         bool              `json:"valid"`
+	BlocksChecked int               `json:"blocks_checked"`
+	BrokenBlock   int               `json:"broken_block"`
+	Failure       ValidationFailure `json:"failure,omitempty"`
+	Reason        string            `json:"reason,omitempty"`
+}
+
+// Err returns nil for a valid chain, or an error describing the first broken block.
+func (r *ValidationReport) Err() error {
+	if r.Valid {
+		return nil
+	}
+	return fmt.Errorf("block %d: %s: %s", r.BrokenBlock, r.Failure, r.Reason)
+}
+
+// Validate walks every block of the chain and reports the first block that breaks it.
+// A chain bootstrapped from a snapshot is validated from the snapshot's last block onwards.
+func (chain *BlockChain) Validate() *ValidationReport {
+	return validateBlocks(chain.Blocks, chain.snapshot.anchor())
+}
+
+// ValidateBlocks checks genesis correctness, index continuity, block hashes, Merkle roots, the link between
+// consecutive blocks and timestamp monotonicity, stopping at the first broken block.
+func ValidateBlocks(blocks []Block) *ValidationReport {
+	return validateBlocks(blocks, nil)
+}
+
+// validateBlocks validates blocks that start at genesis, or that continue the chain after anchor when it is given.
+// Only the index, hash and time of the anchor are used.
+func validateBlocks(blocks []Block, anchor *Block) *ValidationReport {
+	report := &ValidationReport{BrokenBlock: -1}
+
+	if len(blocks) == 0 && anchor == nil {
+		return report.fail(0, FailureEmptyChain, "chain has no genesis block")
+	}
+
+	start := 0
+	prev := anchor
+	var prevTime time.Time
+	if anchor != nil {
+		start = anchor.Index + 1
+		var err error
+		if prevTime, err = anchor.CreatedAt(); err != nil {
+			return report.fail(anchor.Index, FailureInvalidTimestamp, fmt.Sprintf("invalid timestamp: %v", err))
+		}
+	}
+
+	for i := range blocks {
+		block := &blocks[i]
+		index := start + i
+		report.BlocksChecked = i + 1
+
+		if index == 0 {
+			if reason := checkGenesis(block); reason != "" {
+				return report.fail(index, FailureInvalidGenesis, reason)
+			}
+		}
+
+		if block.Version < 0 || block.Version > CurrentBlockVersion {
+			return report.fail(index, FailureUnknownVersion, fmt.Sprintf("block version %d is not supported", block.Version))
+		}
+
+		if block.Index != index {
+			return report.fail(index, FailureIndexGap, fmt.Sprintf("expected index %d, found %d", index, block.Index))
+		}
+
+		if !bytes.Equal(block.Hash, block.CalculateHash()) {
+			return report.fail(index, FailureHashMismatch, fmt.Sprintf("stored hash %x does not match contents", block.Hash))
+		}
+
+		if !bytes.Equal(block.MerkleRoot, MerkleRoot(block.Entries)) {
+			return report.fail(index, FailureMerkleRoot, fmt.Sprintf("Merkle root %x does not match the block entries", block.MerkleRoot))
+		}
+
+		if prev != nil && !bytes.Equal(block.PrevHash, prev.Hash) {
+			return report.fail(index, FailureBrokenLink, fmt.Sprintf("previous hash %x does not match block %d hash %x", block.PrevHash, index-1, prev.Hash))
+		}
+
+		blockTime, err := block.CreatedAt()
+		if err != nil {
+			return report.fail(index, FailureInvalidTimestamp, fmt.Sprintf("invalid timestamp: %v", err))
+		}
+		if prev != nil && blockTime.Before(prevTime) {
+			return report.fail(index, FailureTimestampRegressed, fmt.Sprintf("time %s is before block %d time %s", blockTime.Format(time.RFC3339Nano), index-1, prevTime.Format(time.RFC3339Nano)))
+		}
+		prev = block
+		prevTime = blockTime
+	}
+
+	report.Valid = true
+	return report
+}
+
+// checkGenesis returns why the block is not a valid genesis block, or an empty string if it is.
+func checkGenesis(block *Block) string {
+	if block.Index != 0 {
+		return fmt.Sprintf("genesis block has index %d", block.Index)
+	}
+	if len(block.PrevHash) != 0 {
+		return "genesis block has a previous hash"
+	}
+	envelope, _, err := DecodePayload(block.Data)
+	if err != nil || envelope.Type != PayloadGenesis {
+		return "genesis block does not carry a genesis payload"
+	}
+	return ""
+}
+
+func (r *ValidationReport) fail(i int, failure ValidationFailure, reason string) *ValidationReport {
+	r.Valid = false
+	r.BrokenBlock = i
+	r.Failure = failure
+	r.Reason = reason
+	return r
+}
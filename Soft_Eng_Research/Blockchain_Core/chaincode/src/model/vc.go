@@ -0,0 +1,330 @@
+package model
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	vcContextV1 = "https://www.w3.org/2018/credentials/v1"
+	vcBaseType  = "VerifiableCredential"
+
+	// VCProofType is the proof type of exported credentials. The proof is the issuer's Ed25519
+	// signature over the ledger credential hash, so it is checked by rebuilding the Credential
+	// from the document rather than by canonicalizing the JSON-LD.
+	VCProofType = "Ed25519CredentialHashSignature"
+)
+
+// VerifiableCredential is a credential in the W3C Verifiable Credentials Data Model (JSON-LD) shape.
+type VerifiableCredential struct {
+	Context           []string     `json:"@context"`
+	ID                string       `json:"id"`
+	Type              []string     `json:"type"`
+	Issuer            VCIssuer     `json:"issuer"`
+	IssuanceDate      string       `json:"issuanceDate"`
+	ValidFrom         string       `json:"validFrom,omitempty"`
+	ExpirationDate    string       `json:"expirationDate,omitempty"`
+	Supersedes        string       `json:"supersedes,omitempty"`
+	CredentialSubject VCSubject    `json:"credentialSubject"`
+	Evidence          []VCEvidence `json:"evidence,omitempty"`
+	Proof             *VCProof     `json:"proof,omitempty"`
+}
+
+// VCIssuer identifies the institution that issued the credential.
+type VCIssuer struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// VCSubject identifies the student the credential was issued to. Only what the proof covers is exported,
+// so the student's name and number are left out; they can be disclosed through the claim commitments.
+type VCSubject struct {
+	ID string `json:"id"`
+	// ClaimCommitments holds the hex-encoded selective disclosure commitments of the credential
+	ClaimCommitments map[string]string `json:"claimCommitments,omitempty"`
+}
+
+// VCEvidence describes the document file the credential was issued with.
+type VCEvidence struct {
+	Type         []string `json:"type"`
+	DigestSHA256 string   `json:"digestSHA256"`
+	Size         int64    `json:"size"`
+	MediaType    string   `json:"mediaType"`
+}
+
+// VCProof is the issuer signature embedded in an exported credential.
+type VCProof struct {
+	Type               string `json:"type"`
+	Created            string `json:"created"`
+	VerificationMethod string `json:"verificationMethod"`
+	ProofPurpose       string `json:"proofPurpose"`
+	ProofValue         string `json:"proofValue"`
+	// HashVersion is the serialization format of the signed credential hash
+	HashVersion int `json:"hashVersion,omitempty"`
+	// Approvals are the signed sign-offs of the admins who approved the credential before it was issued
+	Approvals []VCApproval `json:"approvals,omitempty"`
+}
+
+// VCApproval is one admin's approval of an exported credential.
+type VCApproval struct {
+	Created            string `json:"created"`
+	VerificationMethod string `json:"verificationMethod"`
+	ProofValue         string `json:"proofValue"`
+}
+
+// ToVerifiableCredential converts a signed credential, together with its issuing admin and subject student,
+// into a W3C Verifiable Credential with the issuer signature embedded as its proof.
+func ToVerifiableCredential(cred *Credential, issuer *Admin, subject *Student) (*VerifiableCredential, error) {
+	if len(cred.Signature) == 0 {
+		return nil, fmt.Errorf("credential %s is not signed", cred.ID)
+	}
+	if issuer.AdminID != cred.SignerID {
+		return nil, fmt.Errorf("credential %s was signed by %s, not %s", cred.ID, cred.SignerID, issuer.AdminID)
+	}
+	if subject.ID != cred.OwnerID {
+		return nil, fmt.Errorf("credential %s does not belong to student %d", cred.ID, subject.ID)
+	}
+	if cred.Status != "" {
+		return nil, fmt.Errorf("credential %s has status %q, which cannot be exported", cred.ID, cred.Status)
+	}
+
+	vc := &VerifiableCredential{
+		Context: []string{vcContextV1},
+		ID:      "urn:credential:" + cred.ID,
+		Type:    []string{vcBaseType, cred.Type.String() + "Credential"},
+		Issuer: VCIssuer{
+			ID:   "urn:issuer:" + url.PathEscape(cred.Issuer),
+			Name: cred.Issuer,
+		},
+		IssuanceDate: cred.DateIssued.Format(time.RFC3339Nano),
+		CredentialSubject: VCSubject{
+			ID: "urn:student:" + strconv.Itoa(subject.ID),
+		},
+		Proof: &VCProof{
+			Type:               VCProofType,
+			Created:            cred.DateIssued.Format(time.RFC3339Nano),
+			VerificationMethod: "urn:admin:" + cred.SignerID + "#ed25519",
+			ProofPurpose:       "assertionMethod",
+			ProofValue:         base64.RawURLEncoding.EncodeToString(cred.Signature),
+			HashVersion:        cred.HashVersion,
+		},
+	}
+	for _, approval := range cred.Approvals {
+		vc.Proof.Approvals = append(vc.Proof.Approvals, VCApproval{
+			Created:            approval.ApprovedAt.Format(time.RFC3339Nano),
+			VerificationMethod: "urn:admin:" + approval.AdminID + "#ed25519",
+			ProofValue:         base64.RawURLEncoding.EncodeToString(approval.Signature),
+		})
+	}
+	if cred.ValidFrom != nil {
+		vc.ValidFrom = cred.ValidFrom.Format(time.RFC3339Nano)
+	}
+	if cred.ExpiresAt != nil {
+		vc.ExpirationDate = cred.ExpiresAt.Format(time.RFC3339Nano)
+	}
+	if cred.Supersedes != "" {
+		vc.Supersedes = "urn:credential:" + cred.Supersedes
+	}
+	if len(cred.Commitments) > 0 {
+		vc.CredentialSubject.ClaimCommitments = make(map[string]string, len(cred.Commitments))
+		for claim, commitment := range cred.Commitments {
+			vc.CredentialSubject.ClaimCommitments[claim] = hex.EncodeToString(commitment)
+		}
+	}
+	if cred.Document != nil {
+		vc.Evidence = []VCEvidence{{
+			Type:         []string{"DocumentEvidence"},
+			DigestSHA256: hex.EncodeToString(cred.Document.SHA256),
+			Size:         cred.Document.Size,
+			MediaType:    cred.Document.MediaType,
+		}}
+	}
+	return vc, nil
+}
+
+// ToCredential rebuilds the ledger credential described by the document, including its issuer signature.
+func (vc *VerifiableCredential) ToCredential() (*Credential, error) {
+	if !containsString(vc.Context, vcContextV1) {
+		return nil, fmt.Errorf("document does not use the W3C credentials context")
+	}
+	if !containsString(vc.Type, vcBaseType) {
+		return nil, fmt.Errorf("document is not a VerifiableCredential")
+	}
+	if vc.Proof == nil || vc.Proof.Type != VCProofType {
+		return nil, fmt.Errorf("document has no %s proof", VCProofType)
+	}
+
+	id, ok := strings.CutPrefix(vc.ID, "urn:credential:")
+	if !ok || id == "" {
+		return nil, fmt.Errorf("invalid credential id %q", vc.ID)
+	}
+
+	var credentialType CredentialType
+	found := false
+	for _, t := range vc.Type {
+		if name, ok := strings.CutSuffix(t, "Credential"); ok && t != vcBaseType {
+			parsed, err := ParseCredentialType(name)
+			if err != nil {
+				return nil, err
+			}
+			credentialType, found = parsed, true
+		}
+	}
+	if !found {
+		return nil, fmt.Errorf("document has no credential type")
+	}
+
+	dateIssued, err := time.Parse(time.RFC3339Nano, vc.IssuanceDate)
+	if err != nil {
+		return nil, fmt.Errorf("invalid issuance date: %w", err)
+	}
+
+	ownerID, err := strconv.Atoi(strings.TrimPrefix(vc.CredentialSubject.ID, "urn:student:"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid credential subject %q", vc.CredentialSubject.ID)
+	}
+
+	signerID, err := parseVerificationMethod(vc.Proof.VerificationMethod)
+	if err != nil {
+		return nil, err
+	}
+
+	validFrom, err := parseOptionalTime(vc.ValidFrom)
+	if err != nil {
+		return nil, fmt.Errorf("invalid validFrom: %w", err)
+	}
+	expiresAt, err := parseOptionalTime(vc.ExpirationDate)
+	if err != nil {
+		return nil, fmt.Errorf("invalid expiration date: %w", err)
+	}
+
+	var document *DocumentDigest
+	for _, evidence := range vc.Evidence {
+		if !containsString(evidence.Type, "DocumentEvidence") {
+			continue
+		}
+		digest, err := hex.DecodeString(evidence.DigestSHA256)
+		if err != nil {
+			return nil, fmt.Errorf("invalid document digest: %w", err)
+		}
+		document = &DocumentDigest{SHA256: digest, Size: evidence.Size, MediaType: evidence.MediaType}
+	}
+
+	var supersedes string
+	if vc.Supersedes != "" {
+		supersedes, ok = strings.CutPrefix(vc.Supersedes, "urn:credential:")
+		if !ok || supersedes == "" {
+			return nil, fmt.Errorf("invalid superseded credential id %q", vc.Supersedes)
+		}
+	}
+
+	var commitments map[string][]byte
+	if len(vc.CredentialSubject.ClaimCommitments) > 0 {
+		commitments = make(map[string][]byte, len(vc.CredentialSubject.ClaimCommitments))
+		for claim, value := range vc.CredentialSubject.ClaimCommitments {
+			commitment, err := hex.DecodeString(value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid commitment for claim %q: %w", claim, err)
+			}
+			commitments[claim] = commitment
+		}
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(vc.Proof.ProofValue)
+	if err != nil {
+		return nil, fmt.Errorf("invalid proof value: %w", err)
+	}
+
+	var approvals []Approval
+	for _, proof := range vc.Proof.Approvals {
+		adminID, err := parseVerificationMethod(proof.VerificationMethod)
+		if err != nil {
+			return nil, err
+		}
+		approvedAt, err := time.Parse(time.RFC3339Nano, proof.Created)
+		if err != nil {
+			return nil, fmt.Errorf("invalid approval time: %w", err)
+		}
+		approvalSignature, err := base64.RawURLEncoding.DecodeString(proof.ProofValue)
+		if err != nil {
+			return nil, fmt.Errorf("invalid approval proof value: %w", err)
+		}
+		approvals = append(approvals, Approval{AdminID: adminID, ApprovedAt: approvedAt, Signature: approvalSignature})
+	}
+
+	cred := &Credential{
+		ID:          id,
+		OwnerID:     ownerID,
+		Type:        credentialType,
+		Issuer:      vc.Issuer.Name,
+		DateIssued:  dateIssued,
+		ValidFrom:   validFrom,
+		ExpiresAt:   expiresAt,
+		Document:    document,
+		Commitments: commitments,
+		Supersedes:  supersedes,
+		SignerID:    signerID,
+		HashVersion: vc.Proof.HashVersion,
+		Signature:   signature,
+		Approvals:   approvals,
+	}
+	cred.Hash = GenerateCredentialHash(cred)
+	return cred, nil
+}
+
+// ImportVerifiableCredential verifies the proof of a W3C Verifiable Credential document
+// against the registered issuer keys and adds the credential to the chain.
+func (chain *CredentialChain) ImportVerifiableCredential(doc []byte) (*Credential, error) {
+	var vc VerifiableCredential
+	if err := json.Unmarshal(doc, &vc); err != nil {
+		return nil, fmt.Errorf("invalid verifiable credential: %w", err)
+	}
+
+	cred, err := vc.ToCredential()
+	if err != nil {
+		return nil, err
+	}
+	if err := chain.verifyIssuerSignature(cred); err != nil {
+		return nil, err
+	}
+	if err := chain.AddCredentialModel(cred); err != nil {
+		return nil, err
+	}
+	return cred, nil
+}
+
+// parseVerificationMethod returns the ID of the admin named by a verification method.
+func parseVerificationMethod(method string) (string, error) {
+	adminID, ok := strings.CutPrefix(method, "urn:admin:")
+	adminID, _, _ = strings.Cut(adminID, "#")
+	if !ok || adminID == "" {
+		return "", fmt.Errorf("invalid verification method %q", method)
+	}
+	return adminID, nil
+}
+
+func parseOptionalTime(value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(time.RFC3339Nano, value)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+func containsString(values []string, want string) bool {
+	for _, v := range values {
+		if v == want {
+			return true
+		}
+	}
+	return false
+}
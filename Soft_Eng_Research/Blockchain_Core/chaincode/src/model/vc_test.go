@@ -0,0 +1,118 @@
+package model
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+// exportTestCredential issues a credential using every field a verifiable credential carries,
+// and exports it.
+func exportTestCredential(t *testing.T, l *testLedger, s *Student) (*Credential, *VerifiableCredential) {
+	t.Helper()
+	expires := time.Now().Add(24 * time.Hour).UTC()
+	digest := sha256.Sum256([]byte("transcript"))
+	cred := l.issue(t, s, func(cred *Credential) {
+		cred.ExpiresAt = &expires
+		cred.Document = &DocumentDigest{SHA256: digest[:], Size: 10, MediaType: "application/pdf"}
+		if _, err := cred.CommitClaims(map[string]string{"degree": "BSc Computer Science"}); err != nil {
+			t.Fatal(err)
+		}
+	})
+	vc, err := ToVerifiableCredential(cred, l.signer(), s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cred, vc
+}
+
+func TestVerifiableCredentialRoundTrip(t *testing.T) {
+	l := newTestLedger(t)
+	importer := l.replica(t)
+	s := testStudent(1)
+	cred, vc := exportTestCredential(t, l, s)
+
+	imported, err := importer.ImportVerifiableCredential(mustJSON(t, vc))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(imported.Hash, cred.Hash) || !bytes.Equal(imported.Signature, cred.Signature) {
+		t.Fatal("imported credential differs from the exported one")
+	}
+	if !imported.ExpiresAt.Equal(*cred.ExpiresAt) || !bytes.Equal(imported.Document.SHA256, cred.Document.SHA256) {
+		t.Fatalf("imported credential lost fields: %+v", imported)
+	}
+	expectStatus(t, importer.VerifyCredential(cred.ID), StatusValid)
+}
+
+func TestImportVerifiableCredentialRejectsChangedDocuments(t *testing.T) {
+	tests := map[string]func(vc *VerifiableCredential){
+		"issuance date": func(vc *VerifiableCredential) {
+			vc.IssuanceDate = time.Now().Add(-48 * time.Hour).UTC().Format(time.RFC3339Nano)
+		},
+		"expiration date": func(vc *VerifiableCredential) { vc.ExpirationDate = "" },
+		"subject":         func(vc *VerifiableCredential) { vc.CredentialSubject.ID = "urn:student:2" },
+		"evidence":        func(vc *VerifiableCredential) { vc.Evidence = nil },
+		"commitments":     func(vc *VerifiableCredential) { vc.CredentialSubject.ClaimCommitments = nil },
+		"proof":           func(vc *VerifiableCredential) { vc.Proof.ProofValue = vc.Proof.ProofValue[1:] + "A" },
+		"verification method": func(vc *VerifiableCredential) {
+			vc.Proof.VerificationMethod = "urn:admin:dean#ed25519"
+		},
+		"no proof":   func(vc *VerifiableCredential) { vc.Proof = nil },
+		"no context": func(vc *VerifiableCredential) { vc.Context = nil },
+	}
+	for name, change := range tests {
+		t.Run(name, func(t *testing.T) {
+			l := newTestLedger(t)
+			importer := l.replica(t)
+			cred, vc := exportTestCredential(t, l, testStudent(1))
+			change(vc)
+			if _, err := importer.ImportVerifiableCredential(mustJSON(t, vc)); err == nil {
+				t.Fatal("changed document was imported")
+			}
+			if _, err := importer.FindCredentialByID(cred.ID); err == nil {
+				t.Fatal("rejected credential is on the chain")
+			}
+		})
+	}
+}
+
+func TestToVerifiableCredentialRefusals(t *testing.T) {
+	l := newTestLedger(t)
+	s := testStudent(1)
+	cred := l.issue(t, s, nil)
+
+	if _, err := ToVerifiableCredential(cred, l.signers[1], s); err == nil {
+		t.Error("exported a credential as an admin that did not sign it")
+	}
+	if _, err := ToVerifiableCredential(cred, l.signer(), testStudent(2)); err == nil {
+		t.Error("exported a credential for a student it was not issued to")
+	}
+	unsigned := *cred
+	unsigned.Signature = nil
+	if _, err := ToVerifiableCredential(&unsigned, l.signer(), s); err == nil {
+		t.Error("exported an unsigned credential")
+	}
+}
+
+func TestVerifiableCredentialJSONShape(t *testing.T) {
+	l := newTestLedger(t)
+	s := testStudent(1)
+	_, vc := exportTestCredential(t, l, s)
+
+	var doc map[string]interface{}
+	if err := json.Unmarshal(mustJSON(t, vc), &doc); err != nil {
+		t.Fatal(err)
+	}
+	for _, field := range []string{"@context", "id", "type", "issuer", "issuanceDate", "expirationDate", "credentialSubject", "proof"} {
+		if _, ok := doc[field]; !ok {
+			t.Errorf("exported document has no %q", field)
+		}
+	}
+	subject := doc["credentialSubject"].(map[string]interface{})
+	if len(subject) != 2 {
+		t.Errorf("credential subject %v exports more than its ID and commitments", subject)
+	}
+}
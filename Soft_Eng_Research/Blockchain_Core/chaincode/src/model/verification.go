@@ -0,0 +1,215 @@
+package model
+
+import (
+	"bytes"
+	"fmt"
+	"time"
+)
+
+// VerificationCheck names one of the checks performed when verifying a credential.
+type VerificationCheck string
+
+const (
+	CheckContentHash     VerificationCheck = "content-hash"
+	CheckIssuerSignature VerificationCheck = "issuer-signature"
+	CheckChainInclusion  VerificationCheck = "chain-inclusion"
+	CheckRevocation      VerificationCheck = "revocation"
+	CheckExpiry          VerificationCheck = "expiry"
+	CheckIssuerTrust     VerificationCheck = "issuer-trust"
+	CheckDocument        VerificationCheck = "document"
+	CheckDisclosure      VerificationCheck = "disclosure"
+	CheckSupersession    VerificationCheck = "supersession"
+	CheckApprovals       VerificationCheck = "approvals"
+	CheckCheckpoint      VerificationCheck = "checkpoint"
+)
+
+// VerificationStatus is the overall outcome of verifying a credential.
+type VerificationStatus string
+
+const (
+	StatusValid       VerificationStatus = "valid"
+	StatusInvalid     VerificationStatus = "invalid"
+	StatusNotFound    VerificationStatus = "not-found"
+	StatusRevoked     VerificationStatus = "revoked"
+	StatusExpired     VerificationStatus = "expired"
+	StatusNotYetValid VerificationStatus = "not-yet-valid"
+	StatusSuperseded  VerificationStatus = "superseded"
+)
+
+// CheckResult is the outcome of a single verification check.
+type CheckResult struct {
+	Check  VerificationCheck `json:"check"`
+	Passed bool              `json:"passed"`
+	Reason string            `json:"reason,omitempty"`
+}
+
+// VerificationReport lists every check performed on a credential and the overall verdict.
+type VerificationReport struct {
+	CredentialID string             `json:"credential_id"`
+	Status       VerificationStatus `json:"status"`
+	Valid        bool               `json:"valid"`
+	CheckedAt    time.Time          `json:"checked_at"`
+	Checks       []CheckResult      `json:"checks"`
+	Credential   *Credential        `json:"credential,omitempty"`
+	Revocation   *Revocation        `json:"revocation,omitempty"`
+	SupersededBy string             `json:"superseded_by,omitempty"`
+	Disclosed    map[string]string  `json:"disclosed,omitempty"`
+}
+
+// Failed returns the checks that did not pass.
+func (r *VerificationReport) Failed() []CheckResult {
+	var failed []CheckResult
+	for _, check := range r.Checks {
+		if !check.Passed {
+			failed = append(failed, check)
+		}
+	}
+	return failed
+}
+
+// Err returns nil for a valid credential, or an error describing why it is not valid.
+// Revoked credentials return a CredentialRevokedError, and credentials outside their validity window
+// wrap ErrCredentialExpired or ErrCredentialNotYetValid. Superseded credentials wrap ErrCredentialSuperseded.
+func (r *VerificationReport) Err() error {
+	switch r.Status {
+	case StatusValid:
+		return nil
+	case StatusNotFound:
+		return fmt.Errorf("credential with ID %s not found", r.CredentialID)
+	case StatusRevoked:
+		return &CredentialRevokedError{Revocation: r.Revocation}
+	case StatusExpired, StatusNotYetValid:
+		if r.Credential != nil {
+			return r.Credential.CheckValidity(r.CheckedAt)
+		}
+		// Reports of presentations leave the credential out
+		if r.Status == StatusExpired {
+			return fmt.Errorf("credential %s: %w", r.CredentialID, ErrCredentialExpired)
+		}
+		return fmt.Errorf("credential %s: %w", r.CredentialID, ErrCredentialNotYetValid)
+	case StatusSuperseded:
+		return fmt.Errorf("credential %s was replaced by %s: %w", r.CredentialID, r.SupersededBy, ErrCredentialSuperseded)
+	}
+	failed := r.Failed()
+	if len(failed) == 0 {
+		return fmt.Errorf("credential %s is %s", r.CredentialID, r.Status)
+	}
+	return fmt.Errorf("credential %s failed %s check: %s", r.CredentialID, failed[0].Check, failed[0].Reason)
+}
+
+func (r *VerificationReport) add(check VerificationCheck, err error) bool {
+	result := CheckResult{Check: check, Passed: err == nil}
+	if err != nil {
+		result.Reason = err.Error()
+	}
+	r.Checks = append(r.Checks, result)
+	return result.Passed
+}
+
+// VerifyCredential verifies the credential with the given ID as of now.
+func (chain *CredentialChain) VerifyCredential(id string) *VerificationReport {
+	return chain.VerifyCredentialAt(id, time.Now())
+}
+
+// VerifyCredentialAt verifies the credential with the given ID as of the given time.
+// Every check is run and recorded in the report, even after one fails, so a verifier can see all that is wrong.
+func (chain *CredentialChain) VerifyCredentialAt(id string, at time.Time) *VerificationReport {
+	report := &VerificationReport{CredentialID: id, CheckedAt: at}
+
+	cred, block, entry, err := chain.locateCredential(id)
+	if err != nil {
+		report.add(CheckChainInclusion, err)
+		report.Status = StatusNotFound
+		return report
+	}
+	report.Credential = cred
+	// Issuer trust is judged by when the credential was recorded, not only by its issue date
+	recordedAt := chain.indexes().byID[id].RecordedAt
+
+	intact := report.add(CheckContentHash, checkContentHash(cred))
+	intact = report.add(CheckIssuerTrust, chain.checkIssuerTrustedAt(cred, recordedAt)) && intact
+	intact = report.add(CheckIssuerSignature, chain.verifyIssuerSignature(cred)) && intact
+	intact = report.add(CheckApprovals, chain.checkRecordedApprovals(cred)) && intact
+	intact = report.add(CheckChainInclusion, chain.checkInclusion(id, block, entry)) && intact
+
+	revocation, _ := chain.FindRevocation(id)
+	report.Revocation = revocation
+	if revocation != nil {
+		report.add(CheckRevocation, &CredentialRevokedError{Revocation: revocation})
+	} else {
+		report.add(CheckRevocation, nil)
+	}
+
+	report.SupersededBy = chain.SupersededBy(id)
+	if report.SupersededBy != "" {
+		report.add(CheckSupersession, fmt.Errorf("credential %s was replaced by %s: %w", id, report.SupersededBy, ErrCredentialSuperseded))
+	} else {
+		report.add(CheckSupersession, nil)
+	}
+
+	validityErr := cred.CheckValidity(at)
+	report.add(CheckExpiry, validityErr)
+
+	// An invalid credential is reported as such before its revocation, replacement or expiry
+	switch {
+	case !intact:
+		report.Status = StatusInvalid
+	case revocation != nil:
+		report.Status = StatusRevoked
+	case report.SupersededBy != "":
+		report.Status = StatusSuperseded
+	case cred.ValidFrom != nil && at.Before(*cred.ValidFrom):
+		report.Status = StatusNotYetValid
+	case validityErr != nil:
+		report.Status = StatusExpired
+	default:
+		report.Status = StatusValid
+		report.Valid = true
+	}
+	return report
+}
+
+// checkContentHash checks that the stored hash matches the credential's contents.
+func checkContentHash(cred *Credential) error {
+	hash := GenerateCredentialHash(cred)
+	if hash == nil {
+		return fmt.Errorf("unknown credential hash version %d", cred.HashVersion)
+	}
+	if !bytes.Equal(cred.Hash, hash) {
+		return fmt.Errorf("stored hash does not match the credential contents")
+	}
+	return nil
+}
+
+// checkInclusion checks that the credential's entry is committed to by its block and that
+// the block is linked into the chain on both sides.
+func (chain *CredentialChain) checkInclusion(id string, block *Block, entry int) error {
+	// Credentials recorded before the chain's snapshot are covered by the snapshot's signature instead
+	if block == nil {
+		return nil
+	}
+	if !bytes.Equal(block.Hash, block.CalculateHash()) {
+		return fmt.Errorf("block %d hash does not match its contents", block.Index)
+	}
+	prev := chain.block(block.Index - 1)
+	if prev == nil && block.Index == chain.snapshot.height() {
+		prev = chain.snapshot.anchor()
+	}
+	if block.Index > 0 && (prev == nil || !bytes.Equal(block.PrevHash, prev.Hash)) {
+		return fmt.Errorf("block %d does not link to block %d", block.Index, block.Index-1)
+	}
+	if next := chain.block(block.Index + 1); next != nil && !bytes.Equal(next.PrevHash, block.Hash) {
+		return fmt.Errorf("block %d is not linked from block %d", block.Index, block.Index+1)
+	}
+
+	// Credentials in Merkle blocks must also prove their inclusion under the block header
+	if len(block.Entries) > 0 {
+		proof, err := chain.ProveInclusion(block, entry)
+		if err != nil {
+			return err
+		}
+		proof.CredentialID = id
+		return proof.Verify()
+	}
+	return nil
+}
@@ -0,0 +1,133 @@
+package model
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// reportChecks returns whether each check in the report passed, by name.
+func reportChecks(report *VerificationReport) map[VerificationCheck]bool {
+	checks := make(map[VerificationCheck]bool, len(report.Checks))
+	for _, check := range report.Checks {
+		checks[check.Check] = check.Passed
+	}
+	return checks
+}
+
+func TestVerificationReportListsEveryCheck(t *testing.T) {
+	l := newTestLedger(t)
+	cred := l.add(t, testStudent(1), nil)
+
+	report := l.chain.VerifyCredential(cred.ID)
+	expectStatus(t, report, StatusValid)
+	if !report.Valid || report.Err() != nil {
+		t.Fatalf("valid report has Valid %v and Err %v", report.Valid, report.Err())
+	}
+	checks := reportChecks(report)
+	for _, check := range []VerificationCheck{CheckContentHash, CheckIssuerSignature, CheckChainInclusion, CheckRevocation, CheckExpiry, CheckIssuerTrust} {
+		if passed, ok := checks[check]; !ok || !passed {
+			t.Errorf("check %s: performed %v, passed %v", check, ok, passed)
+		}
+	}
+}
+
+func TestVerificationReportNotFound(t *testing.T) {
+	l := newTestLedger(t)
+	report := l.chain.VerifyCredential("missing")
+	expectStatus(t, report, StatusNotFound)
+	if report.Valid || report.Credential != nil {
+		t.Fatal("report of a missing credential is valid or holds a credential")
+	}
+	if err := report.Err(); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Fatalf("Err returned %v", err)
+	}
+}
+
+func TestVerificationReportNamesFailedChecks(t *testing.T) {
+	tests := map[string]struct {
+		change func(cred *Credential)
+		failed []VerificationCheck
+	}{
+		"changed contents": {
+			change: func(cred *Credential) { cred.OwnerID = 2 },
+			failed: []VerificationCheck{CheckContentHash, CheckIssuerSignature},
+		},
+		"rehashed contents": {
+			change: func(cred *Credential) {
+				cred.OwnerID = 2
+				cred.Hash = GenerateCredentialHash(cred)
+			},
+			failed: []VerificationCheck{CheckIssuerSignature},
+		},
+		"unknown issuer": {
+			change: func(cred *Credential) {
+				cred.Issuer = "Diploma Mill"
+				cred.Hash = GenerateCredentialHash(cred)
+			},
+			failed: []VerificationCheck{CheckIssuerTrust, CheckIssuerSignature},
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			l := newTestLedger(t)
+			cred := l.issue(t, testStudent(1), nil)
+			tt.change(cred)
+			// Admission would refuse the credential, so it is written as if by a faulty node
+			if err := l.chain.addBlockEntries([][]byte{credentialEntry(t, cred)}); err != nil {
+				t.Fatal(err)
+			}
+
+			report := l.chain.VerifyCredential(cred.ID)
+			expectStatus(t, report, StatusInvalid)
+			checks := reportChecks(report)
+			for _, check := range tt.failed {
+				if passed, ok := checks[check]; !ok || passed {
+					t.Errorf("check %s: performed %v, passed %v", check, ok, passed)
+				}
+			}
+			if got := len(report.Failed()); got != len(tt.failed) {
+				t.Errorf("%d checks failed, want %d: %+v", got, len(tt.failed), report.Failed())
+			}
+			if err := report.Err(); err == nil || !strings.Contains(err.Error(), string(report.Failed()[0].Check)) {
+				t.Errorf("Err returned %v, want the first failed check", err)
+			}
+		})
+	}
+}
+
+func TestVerificationReportRevoked(t *testing.T) {
+	l := newTestLedger(t)
+	cred := l.add(t, testStudent(1), nil)
+	if err := l.chain.RevokeCredential(cred.ID, "issued in error", l.signer()); err != nil {
+		t.Fatal(err)
+	}
+
+	report := l.chain.VerifyCredential(cred.ID)
+	expectStatus(t, report, StatusRevoked)
+	if checks := reportChecks(report); checks[CheckRevocation] || !checks[CheckIssuerSignature] {
+		t.Fatalf("revoked credential checks: %+v", report.Checks)
+	}
+	var revoked *CredentialRevokedError
+	if err := report.Err(); !errors.As(err, &revoked) || revoked.Revocation.Reason != "issued in error" {
+		t.Fatalf("Err returned %v, want the revocation", err)
+	}
+}
+
+func TestVerificationReportJSON(t *testing.T) {
+	l := newTestLedger(t)
+	cred := l.add(t, testStudent(1), nil)
+
+	var decoded struct {
+		Status VerificationStatus `json:"status"`
+		Valid  bool               `json:"valid"`
+		Checks []CheckResult      `json:"checks"`
+	}
+	if err := json.Unmarshal(mustJSON(t, l.chain.VerifyCredential(cred.ID)), &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if decoded.Status != StatusValid || !decoded.Valid || len(decoded.Checks) == 0 {
+		t.Fatalf("decoded report %+v", decoded)
+	}
+}
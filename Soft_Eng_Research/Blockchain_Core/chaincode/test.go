@@ -1,6 +1,7 @@
 package main
 
 import (
+	"crypto/ed25519"
 	"fmt"
 	"log"
 	"strconv"
@@ -11,11 +12,14 @@ import (
 	"github.com/TsoiEn/Research-Group/Soft_Eng_Research/Blockchain_Core/chaincode/src/model" // Replace with the actual import path of your `model` package
 )
 
-var studentChain = &model.StudentChain{}
+var studentChain *model.StudentChain
 
 // Function to simulate user input for testing admin operations
 // Create a credential blockchain
-var credentialChain = &model.CredentialChain{BlockChain: *model.NewBlockChain()}
+var credentialChain *model.CredentialChain
+
+// certifier signs credentials for Certification Institute
+var certifier *model.Admin
 
 func testAdminOperations() {
 	// Initialize student chain
@@ -37,12 +41,19 @@ func testAdminOperations() {
 		Name:    "Admin User",
 	}
 
+	// Give the admin a signing key
+	if err := admin.GenerateKeys(); err != nil {
+		log.Fatalf("Failed to generate admin keys: %v", err)
+	}
+
+	// Register the issuers used by the tests, with the admin as a signer for Admin University
+	registerTestIssuers(admin)
+
 	// Simulate adding a new student
 	// Simulate adding a new student
 	fmt.Println("Testing AddNewStudent...")
 	newStudent, err := admin.AddNewStudent(202013432, "Mark", "Renee", time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC), 1, studentChain)
 	if err == nil && newStudent != nil {
-		studentChain.Students[newStudent.StudentID] = newStudent
 		fmt.Println("AddNewStudent passed:", newStudent)
 	} else {
 		fmt.Println("AddNewStudent failed. Error:", err)
@@ -60,8 +71,9 @@ func testAdminOperations() {
 	}
 	adminSuccess := admin.AddCredentialAdmin(newStudent, cred.Type, cred.Issuer, cred.DateIssued)
 	if adminSuccess {
-		// Add credential to the blockchain
-		err := credentialChain.AddCredentialModel(&cred)
+		// Add the signed credential to the blockchain
+		signedCred := newStudent.Credentials[len(newStudent.Credentials)-1]
+		err := credentialChain.AddCredentialModel(signedCred)
 		if err != nil {
 			fmt.Println("Failed to add credential to blockchain:", err)
 		} else {
@@ -106,10 +118,12 @@ func testStudentOperations() {
 		Issuer:     "Certification Institute",
 		DateIssued: time.Now(),
 	}
-	studentSuccess := student.AddCredential(cred.Type, cred.Issuer, cred.DateIssued)
+	studentSuccess := student.AddCredential(cred.Type, cred.Issuer, cred.DateIssued, certifier)
 	if studentSuccess {
-		// Add credential to the blockchain
-		err := credentialChain.AddCredentialModel(&cred)
+		// Add the credential, signed by the issuer, to the blockchain
+		added := student.Credentials[len(student.Credentials)-1]
+		err := credentialChain.AddCredentialModel(added)
+		cred = *added
 		if err != nil {
 			fmt.Println("Failed to add credential to blockchain:", err)
 		} else {
@@ -155,16 +169,66 @@ func testStudentOperations() {
 	}
 }
 
+// registerTestIssuers records the accredited issuers used by the tests, signed by a test super-admin
+func registerTestIssuers(signer *model.Admin) {
+	superAdmin := &model.Admin{
+		AdminID: "0",
+		Name:    "Registrar",
+		Role:    model.RoleSuperAdmin,
+	}
+	if err := superAdmin.GenerateKeys(); err != nil {
+		log.Fatalf("Failed to generate super-admin keys: %v", err)
+	}
+	if err := credentialChain.RegisterSuperAdmin(superAdmin.AdminID, superAdmin.PublicKey); err != nil {
+		log.Fatalf("Failed to register super-admin: %v", err)
+	}
+
+	certifier = &model.Admin{
+		AdminID: "2",
+		Name:    "Certifier",
+	}
+	if err := certifier.GenerateKeys(); err != nil {
+		log.Fatalf("Failed to generate certifier keys: %v", err)
+	}
+
+	issuers := []*model.TrustedIssuer{
+		{
+			ID:             "admin-university",
+			Name:           "Admin University",
+			PublicKeys:     map[string]ed25519.PublicKey{signer.AdminID: signer.PublicKey},
+			AccreditedFrom: time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			ID:             "certification-institute",
+			Name:           "Certification Institute",
+			PublicKeys:     map[string]ed25519.PublicKey{certifier.AdminID: certifier.PublicKey},
+			AccreditedFrom: time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC),
+		},
+	}
+	for _, issuer := range issuers {
+		if err := credentialChain.AddTrustedIssuer(superAdmin, issuer); err != nil {
+			log.Fatalf("Failed to register issuer %s: %v", issuer.Name, err)
+		}
+	}
+}
+
 // Helper function to display the current state of the blockchain
 func displayBlockchainState(blockchain *model.BlockChain) {
 	fmt.Println("\n--- Blockchain State ---")
 	for i, block := range blockchain.Blocks {
 		fmt.Printf("Block %d:\n", i)
 		fmt.Printf("  Index: %d\n", block.Index)
-		fmt.Printf("  Timestamp: %s\n", block.Timestamp)
+		createdAt, _ := block.CreatedAt()
+		fmt.Printf("  Version: %d\n", block.Version)
+		fmt.Printf("  Time: %s\n", createdAt.Format(time.RFC3339Nano))
 		fmt.Printf("  Hash: %x\n", block.Hash)
 		fmt.Printf("  PrevHash: %x\n", block.PrevHash)
-		fmt.Printf("  Data: %s\n\n", string(block.Data))
+		fmt.Printf("  Data: %s\n", string(block.Data))
+		fmt.Printf("  MerkleRoot: %x\n", block.MerkleRoot)
+		for j, entry := range block.Entries {
+			fmt.Printf("  Entry %d: %s\n", j, string(entry))
+		}
+		fmt.Println()
 	}
 }
 
@@ -197,6 +261,18 @@ func main() {
 
 	fmt.Println("\nRunning tests for admin and student operations...")
 
+	// Keep the credential chain in memory for the tests
+	credentialChain, err = model.NewCredentialChain(nil)
+	if err != nil {
+		log.Fatalf("Failed to create credential chain: %v", err)
+	}
+
+	// Record student registrations on the same ledger
+	studentChain, err = model.NewStudentChain(credentialChain)
+	if err != nil {
+		log.Fatalf("Failed to create student chain: %v", err)
+	}
+
 	// Run admin operations tests
 	testAdminOperations()
 
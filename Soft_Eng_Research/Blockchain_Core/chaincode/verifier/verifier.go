@@ -0,0 +1,150 @@
+// Package verifier checks credential issuance receipts offline. The only thing it trusts is the hash of a
+// checkpoint block, obtained out of band from the ledger operator; everything else is proven by the receipt.
+//
+// A receipt proves that a credential was issued and has not been altered since. It cannot show that the
+// credential was later revoked or superseded, which only the live chain knows.
+package verifier
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/TsoiEn/Research-Group/Soft_Eng_Research/Blockchain_Core/chaincode/src/model"
+)
+
+// VerifyReceipt checks the receipt against the hash of a trusted checkpoint block as of now.
+func VerifyReceipt(receipt *model.Receipt, checkpoint []byte) *model.VerificationReport {
+	return VerifyReceiptAt(receipt, checkpoint, time.Now())
+}
+
+// VerifyReceiptAt checks the receipt against the hash of a trusted checkpoint block, judging the
+// credential's validity window at the given time.
+func VerifyReceiptAt(receipt *model.Receipt, checkpoint []byte, at time.Time) *model.VerificationReport {
+	report := &model.VerificationReport{Status: model.StatusInvalid, CheckedAt: at.UTC()}
+	if receipt == nil || receipt.Credential == nil {
+		add(report, model.CheckContentHash, fmt.Errorf("receipt holds no credential"))
+		return report
+	}
+	cred := *receipt.Credential
+	report.CredentialID = cred.ID
+	report.Credential = &cred
+
+	intact := add(report, model.CheckContentHash, checkContentHash(&cred))
+	intact = add(report, model.CheckIssuerSignature, checkSignature(receipt, &cred)) && intact
+	intact = add(report, model.CheckChainInclusion, checkInclusion(receipt, &cred)) && intact
+	intact = add(report, model.CheckCheckpoint, checkCheckpoint(receipt, checkpoint)) && intact
+	if !intact {
+		return report
+	}
+
+	// The credential is authentic, so only its validity window is left to judge
+	err := cred.CheckValidity(at)
+	add(report, model.CheckExpiry, err)
+	switch {
+	case err == nil:
+		report.Status = model.StatusValid
+		report.Valid = true
+	case errors.Is(err, model.ErrCredentialNotYetValid):
+		report.Status = model.StatusNotYetValid
+	default:
+		report.Status = model.StatusExpired
+	}
+	return report
+}
+
+func add(report *model.VerificationReport, check model.VerificationCheck, err error) bool {
+	result := model.CheckResult{Check: check, Passed: err == nil}
+	if err != nil {
+		result.Reason = err.Error()
+	}
+	report.Checks = append(report.Checks, result)
+	return result.Passed
+}
+
+// checkContentHash checks that the credential's content still hashes to its recorded hash.
+func checkContentHash(cred *model.Credential) error {
+	hash := model.GenerateCredentialHash(cred)
+	if hash == nil {
+		return fmt.Errorf("credential %s has unknown hash version %d", cred.ID, cred.HashVersion)
+	}
+	if !bytes.Equal(hash, cred.Hash) {
+		return fmt.Errorf("credential %s content does not match its hash", cred.ID)
+	}
+	return nil
+}
+
+// checkSignature checks the issuer signature against the signer key carried by the receipt. The key needs no
+// outside trust: the signature is bound to the credential recorded on the chain, so no other key can verify it.
+func checkSignature(receipt *model.Receipt, cred *model.Credential) error {
+	if len(receipt.IssuerSignature) == 0 {
+		return fmt.Errorf("credential %s is not signed by its issuer", cred.ID)
+	}
+	if receipt.SignerID != cred.SignerID || !bytes.Equal(receipt.IssuerSignature, cred.Signature) {
+		return fmt.Errorf("receipt signature is not the credential's issuer signature")
+	}
+	if len(receipt.SignerKey) != ed25519.PublicKeySize {
+		return fmt.Errorf("receipt has no signer key for admin %q", receipt.SignerID)
+	}
+	if !model.VerifyCredentialSignature(cred, receipt.SignerKey) {
+		return fmt.Errorf("credential %s has an invalid issuer signature", cred.ID)
+	}
+	return nil
+}
+
+// checkInclusion checks that the credential is the entry proven to be in the receipt's block.
+func checkInclusion(receipt *model.Receipt, cred *model.Credential) error {
+	proof := &receipt.Inclusion
+	header := &proof.Header
+	if proof.CredentialID != cred.ID {
+		return fmt.Errorf("inclusion proof is for credential %s, not %s", proof.CredentialID, cred.ID)
+	}
+	if header.Index != receipt.BlockIndex || !bytes.Equal(header.Hash, receipt.BlockHash) || !bytes.Equal(header.PrevHash, receipt.PrevHash) {
+		return fmt.Errorf("inclusion proof is not for block %d", receipt.BlockIndex)
+	}
+	if err := proof.Verify(); err != nil {
+		return err
+	}
+
+	// The proven entry must record this very credential, signature included
+	_, value, err := model.DecodePayload(proof.Entry)
+	if err != nil {
+		return fmt.Errorf("proven entry cannot be decoded: %w", err)
+	}
+	recorded, ok := value.(*model.Credential)
+	if !ok {
+		return fmt.Errorf("proven entry does not record a credential")
+	}
+	if recorded.ID != cred.ID || !bytes.Equal(recorded.Hash, cred.Hash) || !bytes.Equal(recorded.Signature, cred.Signature) {
+		return fmt.Errorf("credential %s does not match the entry recorded in block %d", cred.ID, receipt.BlockIndex)
+	}
+	return nil
+}
+
+// checkCheckpoint checks that the receipt's headers link its block, one block at a time, to the trusted checkpoint.
+// The checkpoint may be any block from the credential's block up to the last header of the receipt.
+func checkCheckpoint(receipt *model.Receipt, checkpoint []byte) error {
+	if len(checkpoint) == 0 {
+		return fmt.Errorf("no trusted checkpoint to verify against")
+	}
+	prev := &receipt.Inclusion.Header
+	if bytes.Equal(prev.Hash, checkpoint) {
+		return nil
+	}
+	for i := range receipt.Headers {
+		header := &receipt.Headers[i]
+		if header.Index != prev.Index+1 || !bytes.Equal(header.PrevHash, prev.Hash) {
+			return fmt.Errorf("block %d does not follow block %d", header.Index, prev.Index)
+		}
+		if !bytes.Equal(header.Hash, header.CalculateHash()) {
+			return fmt.Errorf("block %d header does not match its hash", header.Index)
+		}
+		if bytes.Equal(header.Hash, checkpoint) {
+			return nil
+		}
+		prev = header
+	}
+	return fmt.Errorf("trusted checkpoint is not among blocks %d to %d of the receipt", receipt.Inclusion.Header.Index, prev.Index)
+}
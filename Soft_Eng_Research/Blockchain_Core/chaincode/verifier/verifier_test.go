@@ -0,0 +1,218 @@
+package verifier
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/TsoiEn/Research-Group/Soft_Eng_Research/Blockchain_Core/chaincode/src/model"
+)
+
+const issuerName = "Test University"
+
+// testLedger is an in-memory chain with one trusted issuer signed for by signer.
+type testLedger struct {
+	chain  *model.CredentialChain
+	signer *model.Admin
+}
+
+func newAdmin(t *testing.T, id, role string) *model.Admin {
+	t.Helper()
+	admin := &model.Admin{AdminID: id, Name: "Admin " + id, Role: role}
+	if err := admin.GenerateKeys(); err != nil {
+		t.Fatal(err)
+	}
+	return admin
+}
+
+func newTestLedger(t *testing.T) *testLedger {
+	t.Helper()
+	chain, err := model.NewCredentialChain(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	root := newAdmin(t, "root", model.RoleSuperAdmin)
+	if err := chain.RegisterSuperAdmin(root.AdminID, root.PublicKey); err != nil {
+		t.Fatal(err)
+	}
+	signer := newAdmin(t, "registrar", "")
+	issuer := &model.TrustedIssuer{
+		ID:             "test-university",
+		Name:           issuerName,
+		PublicKeys:     map[string]ed25519.PublicKey{signer.AdminID: signer.PublicKey},
+		AccreditedFrom: time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+	if err := chain.AddTrustedIssuer(root, issuer); err != nil {
+		t.Fatal(err)
+	}
+	return &testLedger{chain: chain, signer: signer}
+}
+
+// add issues a credential to a student and returns its receipt. edit, if given, changes the credential before it is signed.
+func (l *testLedger) add(t *testing.T, owner int, edit func(cred *model.Credential)) *model.Receipt {
+	t.Helper()
+	cred := &model.Credential{Type: model.Certificate, Issuer: issuerName, DateIssued: time.Now().Add(-time.Hour).UTC()}
+	if edit != nil {
+		edit(cred)
+	}
+	if err := l.signer.IssueCredential(&model.Student{ID: owner}, cred); err != nil {
+		t.Fatal(err)
+	}
+	receipt, err := l.chain.AddCredentialWithReceipt(cred)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return receipt
+}
+
+// copyReceipt returns a deep copy of the receipt, made through its JSON form as a holder would keep it.
+func copyReceipt(t *testing.T, receipt *model.Receipt) *model.Receipt {
+	t.Helper()
+	data, err := json.Marshal(receipt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var copied model.Receipt
+	if err := json.Unmarshal(data, &copied); err != nil {
+		t.Fatal(err)
+	}
+	return &copied
+}
+
+func expectStatus(t *testing.T, report *model.VerificationReport, want model.VerificationStatus) {
+	t.Helper()
+	if report.Status != want {
+		t.Fatalf("receipt is %s, want %s; failed checks: %+v", report.Status, want, report.Failed())
+	}
+}
+
+func TestVerifyReceipt(t *testing.T) {
+	l := newTestLedger(t)
+	receipt := l.add(t, 1, nil)
+	issuedAt := l.chain.Checkpoint()
+	l.add(t, 2, nil)
+	l.add(t, 3, nil)
+
+	later, err := l.chain.Receipt(receipt.Credential.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	later = copyReceipt(t, later)
+	// Any block from the credential's own up to the receipt's last header can be the trusted checkpoint
+	for _, checkpoint := range [][]byte{issuedAt, l.chain.Checkpoint()} {
+		report := VerifyReceipt(later, checkpoint)
+		expectStatus(t, report, model.StatusValid)
+		if !report.Valid || report.CredentialID != receipt.Credential.ID {
+			t.Fatalf("report is %+v", report)
+		}
+	}
+	// A receipt made before later blocks cannot reach them
+	expectStatus(t, VerifyReceipt(copyReceipt(t, receipt), l.chain.Checkpoint()), model.StatusInvalid)
+}
+
+func TestVerifyReceiptRejectsChanges(t *testing.T) {
+	other := newAdmin(t, "forger", "")
+	tests := map[string]struct {
+		change func(t *testing.T, r *model.Receipt)
+		failed model.VerificationCheck
+	}{
+		"credential owner": {
+			change: func(t *testing.T, r *model.Receipt) { r.Credential.OwnerID = 2 },
+			failed: model.CheckContentHash,
+		},
+		"rehashed credential": {
+			change: func(t *testing.T, r *model.Receipt) {
+				r.Credential.OwnerID = 2
+				r.Credential.Hash = model.GenerateCredentialHash(r.Credential)
+			},
+			failed: model.CheckIssuerSignature,
+		},
+		"resigned credential": {
+			change: func(t *testing.T, r *model.Receipt) {
+				r.Credential.OwnerID = 2
+				r.Credential.SignerID = other.AdminID
+				if err := other.SignCredential(r.Credential); err != nil {
+					t.Fatal(err)
+				}
+				r.SignerID, r.SignerKey, r.IssuerSignature = other.AdminID, other.PublicKey, r.Credential.Signature
+			},
+			failed: model.CheckChainInclusion,
+		},
+		"signer key": {
+			change: func(t *testing.T, r *model.Receipt) { r.SignerKey = other.PublicKey },
+			failed: model.CheckIssuerSignature,
+		},
+		"inclusion proof": {
+			change: func(t *testing.T, r *model.Receipt) { r.Inclusion.Entry[len(r.Inclusion.Entry)-2] ^= 1 },
+			failed: model.CheckChainInclusion,
+		},
+		"block hash": {
+			change: func(t *testing.T, r *model.Receipt) { r.BlockHash[0] ^= 1 },
+			failed: model.CheckChainInclusion,
+		},
+		"header": {
+			change: func(t *testing.T, r *model.Receipt) { r.Headers[0].MerkleRoot[0] ^= 1 },
+			failed: model.CheckCheckpoint,
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			l := newTestLedger(t)
+			l.add(t, 1, nil)
+			cred := l.add(t, 1, nil).Credential
+			l.add(t, 2, nil)
+			receipt, err := l.chain.Receipt(cred.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			receipt = copyReceipt(t, receipt)
+			tt.change(t, receipt)
+
+			report := VerifyReceipt(receipt, l.chain.Checkpoint())
+			expectStatus(t, report, model.StatusInvalid)
+			for _, check := range report.Failed() {
+				if check.Check == tt.failed {
+					return
+				}
+			}
+			t.Fatalf("%s check did not fail: %+v", tt.failed, report.Checks)
+		})
+	}
+}
+
+func TestVerifyReceiptCheckpoint(t *testing.T) {
+	l := newTestLedger(t)
+	receipt := copyReceipt(t, l.add(t, 1, nil))
+
+	for name, checkpoint := range map[string][]byte{
+		"no checkpoint":      nil,
+		"unknown checkpoint": bytes.Repeat([]byte{1}, 32),
+		"earlier checkpoint": receipt.PrevHash,
+	} {
+		t.Run(name, func(t *testing.T) {
+			report := VerifyReceipt(receipt, checkpoint)
+			expectStatus(t, report, model.StatusInvalid)
+			if failed := report.Failed(); len(failed) != 1 || failed[0].Check != model.CheckCheckpoint {
+				t.Fatalf("failed checks are %+v, want only %s", failed, model.CheckCheckpoint)
+			}
+		})
+	}
+	expectStatus(t, VerifyReceipt(nil, l.chain.Checkpoint()), model.StatusInvalid)
+}
+
+func TestVerifyReceiptValidityWindow(t *testing.T) {
+	l := newTestLedger(t)
+	validFrom := time.Now().Add(time.Hour)
+	expires := time.Now().Add(24 * time.Hour)
+	receipt := copyReceipt(t, l.add(t, 1, func(cred *model.Credential) {
+		cred.ValidFrom = &validFrom
+		cred.ExpiresAt = &expires
+	}))
+	checkpoint := l.chain.Checkpoint()
+
+	expectStatus(t, VerifyReceiptAt(receipt, checkpoint, time.Now()), model.StatusNotYetValid)
+	expectStatus(t, VerifyReceiptAt(receipt, checkpoint, validFrom), model.StatusValid)
+	expectStatus(t, VerifyReceiptAt(receipt, checkpoint, expires), model.StatusExpired)
+}
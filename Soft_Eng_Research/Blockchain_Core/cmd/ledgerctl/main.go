@@ -0,0 +1,177 @@
+// Command ledgerctl backs up, restores and inspects the credential ledger outside the chaincode process.
+//
+// Usage:
+//
+//	ledgerctl export -store DIR [-format jsonl|binary] [-o FILE]
+//	ledgerctl import -in FILE -store DIR
+//	ledgerctl verify -in FILE [-store DIR]
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/TsoiEn/Research-Group/Soft_Eng_Research/Blockchain_Core/chaincode/src/model"
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+	}
+
+	var err error
+	switch os.Args[1] {
+	case "export":
+		err = runExport(os.Args[2:])
+	case "import":
+		err = runImport(os.Args[2:])
+	case "verify":
+		err = runVerify(os.Args[2:])
+	default:
+		usage()
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "ledgerctl:", err)
+		os.Exit(1)
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: ledgerctl export|import|verify [flags]")
+	os.Exit(2)
+}
+
+// runExport writes the chain in a ledger store to a file or standard output.
+func runExport(args []string) error {
+	flags := flag.NewFlagSet("export", flag.ExitOnError)
+	storeDir := flags.String("store", "", "ledger store directory to export")
+	formatName := flags.String("format", string(model.FormatJSONLines), "export format: jsonl or binary")
+	output := flags.String("o", "", "output file (default standard output)")
+	flags.Parse(args)
+
+	if *storeDir == "" {
+		return fmt.Errorf("export needs -store")
+	}
+	format, err := model.ParseExportFormat(*formatName)
+	if err != nil {
+		return err
+	}
+
+	chain, err := readChain(*storeDir)
+	if err != nil {
+		return err
+	}
+
+	var w io.Writer = os.Stdout
+	if *output != "" {
+		file, err := os.Create(*output)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		w = file
+	}
+	if err := chain.Export(w, format); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "exported %d blocks as %s\n", len(chain.Blocks), format)
+	return nil
+}
+
+// runImport verifies an exported chain and writes it to a new, empty ledger store.
+func runImport(args []string) error {
+	flags := flag.NewFlagSet("import", flag.ExitOnError)
+	input := flags.String("in", "", "exported chain to import")
+	storeDir := flags.String("store", "", "empty ledger store directory to import into")
+	flags.Parse(args)
+
+	if *input == "" || *storeDir == "" {
+		return fmt.Errorf("import needs -in and -store")
+	}
+	blocks, report, err := readExport(*input)
+	if err != nil {
+		return err
+	}
+	printReport(report)
+	if err := report.Err(); err != nil {
+		return fmt.Errorf("not importing %s: %w", *input, err)
+	}
+
+	store, err := model.OpenLedgerStore(*storeDir)
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+	chain, err := model.ImportChain(blocks, store)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("imported %d blocks into %s\n", len(chain.Blocks), *storeDir)
+	return nil
+}
+
+// runVerify checks an exported chain and, when given a ledger store, where the two chains diverge.
+func runVerify(args []string) error {
+	flags := flag.NewFlagSet("verify", flag.ExitOnError)
+	input := flags.String("in", "", "exported chain to verify")
+	storeDir := flags.String("store", "", "ledger store to compare the export against")
+	flags.Parse(args)
+
+	if *input == "" {
+		return fmt.Errorf("verify needs -in")
+	}
+	blocks, report, err := readExport(*input)
+	if err != nil {
+		return err
+	}
+	printReport(report)
+
+	if *storeDir != "" {
+		chain, err := readChain(*storeDir)
+		if err != nil {
+			return err
+		}
+		if i := model.FirstDivergence(chain.Blocks, blocks); i >= 0 {
+			fmt.Printf("export diverges from %s at block %d (store has %d blocks, export has %d)\n", *storeDir, i, len(chain.Blocks), len(blocks))
+		} else {
+			fmt.Printf("export matches %s\n", *storeDir)
+		}
+	}
+	return report.Err()
+}
+
+// readChain reads the chain in a ledger store without writing to it. An empty store is refused,
+// since opening it as a chain would write a genesis block into it.
+func readChain(dir string) (*model.CredentialChain, error) {
+	if _, err := os.Stat(dir); err != nil {
+		return nil, err
+	}
+	blocks, err := model.ReadLedgerStore(dir)
+	if err != nil {
+		return nil, err
+	}
+	if len(blocks) == 0 {
+		return nil, fmt.Errorf("%s holds no blocks", dir)
+	}
+	return model.LoadCredentialChain(blocks, nil)
+}
+
+func readExport(path string) ([]model.Block, *model.ImportReport, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer file.Close()
+	return model.ImportBlocks(file)
+}
+
+func printReport(report *model.ImportReport) {
+	if report.Valid {
+		fmt.Printf("%s export: %d blocks, hash chain verified\n", report.Format, report.Blocks)
+		return
+	}
+	fmt.Printf("%s export: %d blocks read, chain diverges at block %d (%s): %s\n",
+		report.Format, report.Blocks, report.Block, report.Position, report.Reason)
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/TsoiEn/Research-Group/Soft_Eng_Research/Blockchain_Core/chaincode/src/model"
+)
+
+// newStore writes a chain of a genesis block and blocks opaque blocks to a new ledger store, and returns its directory.
+func newStore(t *testing.T, blocks int) string {
+	t.Helper()
+	dir := filepath.Join(t.TempDir(), "store")
+	store, err := model.OpenLedgerStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	chain, err := model.NewBlockChain(store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < blocks; i++ {
+		if err := chain.AddBlock([]byte(fmt.Sprintf("block data %d", i))); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+// captureOutput runs fn and returns what it wrote to standard output.
+func captureOutput(t *testing.T, fn func() error) (string, error) {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = stdout }()
+
+	read := make(chan string)
+	go func() {
+		out, _ := io.ReadAll(r)
+		read <- string(out)
+	}()
+	fnErr := fn()
+	w.Close()
+	return <-read, fnErr
+}
+
+func TestExportImportRoundTrip(t *testing.T) {
+	for _, format := range []string{"jsonl", "binary"} {
+		t.Run(format, func(t *testing.T) {
+			source := newStore(t, 3)
+			export := filepath.Join(t.TempDir(), "ledger."+format)
+			if err := runExport([]string{"-store", source, "-format", format, "-o", export}); err != nil {
+				t.Fatal(err)
+			}
+
+			target := filepath.Join(t.TempDir(), "imported")
+			if err := runImport([]string{"-in", export, "-store", target}); err != nil {
+				t.Fatal(err)
+			}
+			want, err := model.ReadLedgerStore(source)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := model.ReadLedgerStore(target)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(got) != len(want) || model.FirstDivergence(got, want) >= 0 {
+				t.Fatalf("imported store holds %d blocks diverging from the source", len(got))
+			}
+
+			out, err := captureOutput(t, func() error {
+				return runVerify([]string{"-in", export, "-store", target})
+			})
+			if err != nil || !strings.Contains(out, "export matches") {
+				t.Fatalf("verify returned %v:\n%s", err, out)
+			}
+		})
+	}
+}
+
+func TestImportRefusesBrokenExport(t *testing.T) {
+	blocks, err := model.ReadLedgerStore(newStore(t, 3))
+	if err != nil {
+		t.Fatal(err)
+	}
+	blocks[2].Data = []byte("changed")
+	export := filepath.Join(t.TempDir(), "broken.jsonl")
+	file, err := os.Create(export)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := model.ExportBlocks(file, blocks, model.FormatJSONLines); err != nil {
+		t.Fatal(err)
+	}
+	file.Close()
+
+	out, err := captureOutput(t, func() error {
+		return runVerify([]string{"-in", export})
+	})
+	if err == nil || !strings.Contains(out, "diverges at block 2") {
+		t.Fatalf("verify of a broken export returned %v:\n%s", err, out)
+	}
+
+	target := filepath.Join(t.TempDir(), "imported")
+	if _, err := captureOutput(t, func() error {
+		return runImport([]string{"-in", export, "-store", target})
+	}); err == nil {
+		t.Fatal("a broken export was imported")
+	}
+	if blocks, _ := model.ReadLedgerStore(target); len(blocks) != 0 {
+		t.Fatalf("refused import wrote %d blocks", len(blocks))
+	}
+}
+
+func TestVerifyReportsDivergingStore(t *testing.T) {
+	export := filepath.Join(t.TempDir(), "ledger.jsonl")
+	if err := runExport([]string{"-store", newStore(t, 3), "-o", export}); err != nil {
+		t.Fatal(err)
+	}
+	// Another chain shares nothing past its own genesis block
+	other := newStore(t, 1)
+	out, err := captureOutput(t, func() error {
+		return runVerify([]string{"-in", export, "-store", other})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out, "export diverges from "+other) {
+		t.Fatalf("verify did not report the divergence:\n%s", out)
+	}
+}
+
+func TestCommandRefusals(t *testing.T) {
+	export := filepath.Join(t.TempDir(), "ledger.jsonl")
+	source := newStore(t, 1)
+	if err := runExport([]string{"-store", source, "-o", export}); err != nil {
+		t.Fatal(err)
+	}
+	empty := t.TempDir()
+
+	tests := map[string]func() error{
+		"export without store":  func() error { return runExport(nil) },
+		"export unknown format": func() error { return runExport([]string{"-store", source, "-format", "xml"}) },
+		"export missing store":  func() error { return runExport([]string{"-store", filepath.Join(empty, "missing")}) },
+		"export empty store":    func() error { return runExport([]string{"-store", empty}) },
+		"import without input":  func() error { return runImport([]string{"-store", empty}) },
+		"import into a store":   func() error { return runImport([]string{"-in", export, "-store", source}) },
+		"verify without input":  func() error { return runVerify(nil) },
+	}
+	for name, run := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := captureOutput(t, run); err == nil {
+				t.Fatal("command succeeded")
+			}
+		})
+	}
+}
@@ -0,0 +1,275 @@
+// Command verifycred tells whether a credential, and the document presented with it, is genuine.
+//
+// Usage:
+//
+//	verifycred -id ID -chain FILE -keys FILE [-checkpoint HEX] [-doc FILE] [-json]
+//	verifycred -receipt FILE -chain FILE -keys FILE [-checkpoint HEX] [-doc FILE] [-json]
+//	verifycred -receipt FILE -checkpoint HEX [-doc FILE] [-json]
+//
+// Against a chain exported with ledgerctl the credential is verified with CredentialChain.VerifyCredential,
+// trusting the super-admin keys in the -keys file (a JSON object of admin IDs to base64 Ed25519 public keys)
+// for the issuer registry. A -checkpoint given with a chain must be the hash of one of its blocks.
+// Without a chain only a receipt can be verified, against the checkpoint alone, and revocations are not seen.
+//
+// The exit status is 0 for a valid credential, 1 for any other verdict and 2 when no verdict could be reached.
+package main
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/TsoiEn/Research-Group/Soft_Eng_Research/Blockchain_Core/chaincode/src/model"
+	"github.com/TsoiEn/Research-Group/Soft_Eng_Research/Blockchain_Core/chaincode/verifier"
+)
+
+// options are the inputs of a verification.
+type options struct {
+	ID         string
+	Receipt    string
+	Chain      string
+	Keys       string
+	Checkpoint string
+	Document   string
+}
+
+func main() {
+	var opts options
+	flags := flag.NewFlagSet("verifycred", flag.ExitOnError)
+	flags.StringVar(&opts.ID, "id", "", "ID of the credential to verify")
+	flags.StringVar(&opts.Receipt, "receipt", "", "issuance receipt of the credential to verify")
+	flags.StringVar(&opts.Chain, "chain", "", "exported chain to verify the credential against")
+	flags.StringVar(&opts.Keys, "keys", "", "JSON file of the super-admin keys trusted for the issuer registry")
+	flags.StringVar(&opts.Checkpoint, "checkpoint", "", "hex hash of a trusted block")
+	flags.StringVar(&opts.Document, "doc", "", "document presented with the credential")
+	asJSON := flags.Bool("json", false, "print the verdict as JSON")
+	flags.Parse(os.Args[1:])
+
+	report, err := verify(&opts)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "verifycred:", err)
+		os.Exit(2)
+	}
+
+	if *asJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		encoder.Encode(report)
+	} else {
+		printReport(report)
+	}
+	if !report.Valid {
+		os.Exit(1)
+	}
+}
+
+// verify checks the credential against the exported chain when one is given, and the receipt against the checkpoint otherwise.
+func verify(opts *options) (*model.VerificationReport, error) {
+	if (opts.ID == "") == (opts.Receipt == "") {
+		return nil, fmt.Errorf("give either -id or -receipt")
+	}
+
+	var receipt *model.Receipt
+	if opts.Receipt != "" {
+		var err error
+		if receipt, err = readReceipt(opts.Receipt); err != nil {
+			return nil, err
+		}
+		opts.ID = receipt.Credential.ID
+	}
+
+	if opts.Chain != "" {
+		return verifyAgainstChain(opts, receipt)
+	}
+	if receipt == nil {
+		return nil, fmt.Errorf("a credential ID can only be verified against an exported chain; give -chain")
+	}
+	if opts.Checkpoint == "" {
+		return nil, fmt.Errorf("a receipt needs -chain or -checkpoint to be verified against")
+	}
+	return verifyReceipt(opts, receipt)
+}
+
+// verifyAgainstChain verifies the credential with the chain's own verification, including revocations.
+func verifyAgainstChain(opts *options, receipt *model.Receipt) (*model.VerificationReport, error) {
+	if opts.Keys == "" {
+		return nil, fmt.Errorf("verifying against a chain needs the super-admin keys; give -keys")
+	}
+	keys, err := readKeys(opts.Keys)
+	if err != nil {
+		return nil, err
+	}
+	chain, err := readChain(opts.Chain, keys)
+	if err != nil {
+		return nil, err
+	}
+
+	// A trusted checkpoint pins the export to the chain the operator vouches for
+	if opts.Checkpoint != "" {
+		checkpoint, err := parseCheckpoint(opts.Checkpoint)
+		if err != nil {
+			return nil, err
+		}
+		if !containsBlock(chain.Blocks, checkpoint) {
+			return nil, fmt.Errorf("%s does not contain the trusted checkpoint %s", opts.Chain, opts.Checkpoint)
+		}
+	}
+
+	var report *model.VerificationReport
+	if opts.Document != "" {
+		file, err := os.Open(opts.Document)
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+		report = chain.VerifyDocument(opts.ID, file)
+	} else {
+		report = chain.VerifyCredential(opts.ID)
+	}
+
+	// The receipt must be for the credential recorded on the chain, not an altered copy of it
+	if receipt != nil && report.Credential != nil && !bytes.Equal(receipt.Credential.Hash, report.Credential.Hash) {
+		fail(report, model.CheckChainInclusion, fmt.Errorf("receipt does not match credential %s as recorded on the chain", opts.ID))
+	}
+	return report, nil
+}
+
+// verifyReceipt verifies the receipt offline against the trusted checkpoint.
+func verifyReceipt(opts *options, receipt *model.Receipt) (*model.VerificationReport, error) {
+	checkpoint, err := parseCheckpoint(opts.Checkpoint)
+	if err != nil {
+		return nil, err
+	}
+	report := verifier.VerifyReceipt(receipt, checkpoint)
+	if opts.Document == "" {
+		return report, nil
+	}
+
+	cred := report.Credential
+	if cred.Document == nil {
+		fail(report, model.CheckDocument, fmt.Errorf("credential %s has no attached document", cred.ID))
+		return report, nil
+	}
+	file, err := os.Open(opts.Document)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	presented, err := model.NewDocumentDigest(file, cred.Document.MediaType)
+	if err == nil {
+		err = cred.Document.Matches(presented)
+	}
+	if err != nil {
+		fail(report, model.CheckDocument, err)
+		return report, nil
+	}
+	report.Checks = append(report.Checks, model.CheckResult{Check: model.CheckDocument, Passed: true})
+	return report, nil
+}
+
+// fail records a failed check, which makes the credential invalid.
+func fail(report *model.VerificationReport, check model.VerificationCheck, err error) {
+	report.Checks = append(report.Checks, model.CheckResult{Check: check, Reason: err.Error()})
+	report.Status = model.StatusInvalid
+	report.Valid = false
+}
+
+func readReceipt(path string) (*model.Receipt, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var receipt model.Receipt
+	if err := json.Unmarshal(data, &receipt); err != nil {
+		return nil, fmt.Errorf("%s is not a receipt: %w", path, err)
+	}
+	if receipt.Credential == nil || receipt.Credential.ID == "" {
+		return nil, fmt.Errorf("%s holds no credential", path)
+	}
+	return &receipt, nil
+}
+
+func readKeys(path string) (map[string]ed25519.PublicKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var keys map[string]ed25519.PublicKey
+	if err := json.Unmarshal(data, &keys); err != nil {
+		return nil, fmt.Errorf("%s is not a super-admin key file: %w", path, err)
+	}
+	for adminID, key := range keys {
+		if len(key) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("super-admin %q has a %d-byte key, not an Ed25519 public key", adminID, len(key))
+		}
+	}
+	return keys, nil
+}
+
+// readChain reads an exported chain, refusing one whose hash chain is broken.
+func readChain(path string, keys map[string]ed25519.PublicKey) (*model.CredentialChain, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	blocks, report, err := model.ImportBlocks(file)
+	if err != nil {
+		return nil, err
+	}
+	if err := report.Err(); err != nil {
+		return nil, fmt.Errorf("%s is not a valid chain: %w", path, err)
+	}
+	return model.LoadCredentialChain(blocks, keys)
+}
+
+func parseCheckpoint(value string) ([]byte, error) {
+	checkpoint, err := hex.DecodeString(strings.TrimSpace(value))
+	if err != nil || len(checkpoint) == 0 {
+		return nil, fmt.Errorf("checkpoint %q is not a hex block hash", value)
+	}
+	return checkpoint, nil
+}
+
+func containsBlock(blocks []model.Block, hash []byte) bool {
+	for i := range blocks {
+		if bytes.Equal(blocks[i].Hash, hash) {
+			return true
+		}
+	}
+	return false
+}
+
+func printReport(report *model.VerificationReport) {
+	fmt.Printf("Credential %s is %s\n", report.CredentialID, strings.ToUpper(string(report.Status)))
+	if cred := report.Credential; cred != nil {
+		fmt.Printf("  type:    %s\n", cred.Type)
+		fmt.Printf("  issuer:  %s\n", cred.Issuer)
+		fmt.Printf("  owner:   %d\n", cred.OwnerID)
+		fmt.Printf("  issued:  %s\n", cred.DateIssued.Format("2006-01-02"))
+		if cred.ExpiresAt != nil {
+			fmt.Printf("  expires: %s\n", cred.ExpiresAt.Format("2006-01-02"))
+		}
+	}
+	if revocation := report.Revocation; revocation != nil {
+		fmt.Printf("  revoked: %s by %s: %s\n", revocation.RevokedAt.Format("2006-01-02"), revocation.RevokedBy, revocation.Reason)
+	}
+	if report.SupersededBy != "" {
+		fmt.Printf("  replaced by: %s\n", report.SupersededBy)
+	}
+
+	fmt.Println("Checks:")
+	for _, check := range report.Checks {
+		if check.Passed {
+			fmt.Printf("  ok    %s\n", check.Check)
+		} else {
+			fmt.Printf("  FAIL  %s: %s\n", check.Check, check.Reason)
+		}
+	}
+	fmt.Printf("Checked at %s\n", report.CheckedAt.Format("2006-01-02 15:04:05 MST"))
+}
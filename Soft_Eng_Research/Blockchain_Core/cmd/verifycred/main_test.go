@@ -0,0 +1,201 @@
+package main
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/TsoiEn/Research-Group/Soft_Eng_Research/Blockchain_Core/chaincode/src/model"
+)
+
+// fixture is a ledger written out as the files verifycred reads: an exported chain, the super-admin key file,
+// and the receipt and document of a credential.
+type fixture struct {
+	dir        string
+	chain      *model.CredentialChain
+	signer     *model.Admin
+	credential *model.Credential
+	receipt    string
+	document   string
+	checkpoint string
+}
+
+var document = []byte("%PDF-1.7\ntranscript of records")
+
+func writeFile(t *testing.T, path string, data []byte) string {
+	t.Helper()
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func writeJSON(t *testing.T, path string, value interface{}) string {
+	t.Helper()
+	data, err := json.Marshal(value)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return writeFile(t, path, data)
+}
+
+func newFixture(t *testing.T) *fixture {
+	t.Helper()
+	f := &fixture{dir: t.TempDir()}
+	chain, err := model.NewCredentialChain(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.chain = chain
+	root := &model.Admin{AdminID: "root", Role: model.RoleSuperAdmin}
+	f.signer = &model.Admin{AdminID: "registrar"}
+	for _, admin := range []*model.Admin{root, f.signer} {
+		if err := admin.GenerateKeys(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := chain.RegisterSuperAdmin(root.AdminID, root.PublicKey); err != nil {
+		t.Fatal(err)
+	}
+	issuer := &model.TrustedIssuer{
+		ID:             "test-university",
+		Name:           "Test University",
+		PublicKeys:     map[string]ed25519.PublicKey{f.signer.AdminID: f.signer.PublicKey},
+		AccreditedFrom: time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+	if err := chain.AddTrustedIssuer(root, issuer); err != nil {
+		t.Fatal(err)
+	}
+	writeJSON(t, f.path("keys.json"), map[string]ed25519.PublicKey{root.AdminID: root.PublicKey})
+
+	f.credential = &model.Credential{Type: model.Certificate, Issuer: issuer.Name, DateIssued: time.Now().Add(-time.Hour).UTC()}
+	if err := f.credential.AttachDocument(bytes.NewReader(document), ""); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.signer.IssueCredential(&model.Student{ID: 1}, f.credential); err != nil {
+		t.Fatal(err)
+	}
+	receipt, err := chain.AddCredentialWithReceipt(f.credential)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.receipt = writeJSON(t, f.path("receipt.json"), receipt)
+	f.document = writeFile(t, f.path("transcript.pdf"), document)
+	f.checkpoint = hex.EncodeToString(chain.Checkpoint())
+	f.export(t)
+	return f
+}
+
+func (f *fixture) path(name string) string {
+	return filepath.Join(f.dir, name)
+}
+
+// export writes the chain as it is now to chain.jsonl.
+func (f *fixture) export(t *testing.T) {
+	t.Helper()
+	file, err := os.Create(f.path("chain.jsonl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	if err := f.chain.Export(file, model.FormatJSONLines); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func expectStatus(t *testing.T, opts *options, want model.VerificationStatus) *model.VerificationReport {
+	t.Helper()
+	report, err := verify(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Status != want || report.Valid != (want == model.StatusValid) {
+		t.Fatalf("credential is %s, want %s; failed checks: %+v", report.Status, want, report.Failed())
+	}
+	return report
+}
+
+func TestVerifyAgainstChain(t *testing.T) {
+	f := newFixture(t)
+	chain, keys := f.path("chain.jsonl"), f.path("keys.json")
+
+	expectStatus(t, &options{ID: f.credential.ID, Chain: chain, Keys: keys, Checkpoint: f.checkpoint}, model.StatusValid)
+	expectStatus(t, &options{Receipt: f.receipt, Chain: chain, Keys: keys, Document: f.document}, model.StatusValid)
+	wrong := writeFile(t, f.path("other.pdf"), []byte("%PDF-1.7\nanother file"))
+	expectStatus(t, &options{ID: f.credential.ID, Chain: chain, Keys: keys, Document: wrong}, model.StatusInvalid)
+
+	if err := f.chain.RevokeCredential(f.credential.ID, "issued in error", f.signer); err != nil {
+		t.Fatal(err)
+	}
+	f.export(t)
+	report := expectStatus(t, &options{Receipt: f.receipt, Chain: chain, Keys: keys}, model.StatusRevoked)
+	if report.Revocation == nil || report.Revocation.Reason != "issued in error" {
+		t.Fatalf("report does not hold the revocation: %+v", report.Revocation)
+	}
+	// Offline, the receipt still proves issuance, since only the chain records revocations
+	expectStatus(t, &options{Receipt: f.receipt, Checkpoint: f.checkpoint}, model.StatusValid)
+}
+
+func TestVerifyReceiptOffline(t *testing.T) {
+	f := newFixture(t)
+	expectStatus(t, &options{Receipt: f.receipt, Checkpoint: f.checkpoint, Document: f.document}, model.StatusValid)
+
+	wrong := writeFile(t, f.path("other.pdf"), []byte("%PDF-1.7\nanother file"))
+	report := expectStatus(t, &options{Receipt: f.receipt, Checkpoint: f.checkpoint, Document: wrong}, model.StatusInvalid)
+	if failed := report.Failed(); len(failed) != 1 || failed[0].Check != model.CheckDocument {
+		t.Fatalf("failed checks are %+v, want only %s", failed, model.CheckDocument)
+	}
+	other := hex.EncodeToString(make([]byte, 32))
+	expectStatus(t, &options{Receipt: f.receipt, Checkpoint: other}, model.StatusInvalid)
+}
+
+func TestVerifyRefusesAlteredReceipt(t *testing.T) {
+	f := newFixture(t)
+	var receipt model.Receipt
+	data, err := os.ReadFile(f.receipt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(data, &receipt); err != nil {
+		t.Fatal(err)
+	}
+	receipt.Credential.OwnerID = 2
+	receipt.Credential.Hash = model.GenerateCredentialHash(receipt.Credential)
+	altered := writeJSON(t, f.path("altered.json"), &receipt)
+
+	expectStatus(t, &options{Receipt: altered, Chain: f.path("chain.jsonl"), Keys: f.path("keys.json")}, model.StatusInvalid)
+	expectStatus(t, &options{Receipt: altered, Checkpoint: f.checkpoint}, model.StatusInvalid)
+}
+
+func TestVerifyRefusals(t *testing.T) {
+	f := newFixture(t)
+	chain, keys := f.path("chain.jsonl"), f.path("keys.json")
+	badKeys := writeJSON(t, f.path("bad-keys.json"), map[string][]byte{"root": []byte("short")})
+	other := newFixture(t)
+
+	tests := map[string]*options{
+		"no credential":              {Chain: chain, Keys: keys},
+		"id and receipt":             {ID: f.credential.ID, Receipt: f.receipt, Chain: chain, Keys: keys},
+		"id without chain":           {ID: f.credential.ID, Checkpoint: f.checkpoint},
+		"receipt without checkpoint": {Receipt: f.receipt},
+		"chain without keys":         {ID: f.credential.ID, Chain: chain},
+		"short key":                  {ID: f.credential.ID, Chain: chain, Keys: badKeys},
+		"checkpoint not hex":         {Receipt: f.receipt, Checkpoint: "not a hash"},
+		"checkpoint of another chain": {
+			ID: f.credential.ID, Chain: chain, Keys: keys, Checkpoint: other.checkpoint,
+		},
+		"missing receipt": {Receipt: f.path("missing.json"), Checkpoint: f.checkpoint},
+	}
+	for name, opts := range tests {
+		t.Run(name, func(t *testing.T) {
+			if report, err := verify(opts); err == nil {
+				t.Fatalf("verify reached verdict %s", report.Status)
+			}
+		})
+	}
+}
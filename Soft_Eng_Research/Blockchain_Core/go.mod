@@ -1,5 +1,5 @@
 module github.com/TsoiEn/Research-Group/Soft_Eng_Research/Blockchain_Core
 
-go 1.23.2
+go 1.24.0
 
 require golang.org/x/exp v0.0.0-20241108190413-2d47ceb2692f